@@ -0,0 +1,32 @@
+// Package analysis is an internal package of the tool Crawl,
+// responsible for analyzing the results of a completed crawl. Its
+// functions operate on streams of data.Result, such as those
+// produced by crawl spider or crawl list.
+package analysis
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// ReadResults decodes newline-delimited JSON results from in, as
+// written by the crawler, and calls fn with each. It stops at the
+// first error returned by fn.
+func ReadResults(in io.Reader, fn func(*data.Result) error) error {
+	dec := json.NewDecoder(in)
+	for {
+		r := &data.Result{}
+		err := dec.Decode(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// Kinds of problem reported in a CanonicalFinding.
+const (
+	CanonicalNotCrawled = "canonical_not_crawled"
+	CanonicalRedirects  = "canonical_redirects"
+	CanonicalNot200     = "canonical_not_200"
+	CanonicalNoindex    = "canonical_noindex"
+	CanonicalChain      = "canonical_chain"
+	CanonicalLoop       = "canonical_loop"
+	CanonicalConflict   = "header_conflict"
+)
+
+// CanonicalFinding describes the problems with the canonical of a
+// single page. Canonical is the canonical the page declares: from its
+// HTML if present, otherwise from its Link header. Chain is the
+// sequence of addresses reached by following canonicals and
+// redirects from the page, ending at Final, the address where the
+// chain stops.
+type CanonicalFinding struct {
+	Address         string
+	Canonical       string
+	HeaderCanonical string `json:",omitempty"`
+	Final           string `json:",omitempty"`
+	Chain           []string
+	Issues          []string
+}
+
+// canonicalPage is what a CanonicalAnalyzer retains about a page.
+type canonicalPage struct {
+	status          int
+	noindex         bool
+	redirect        string
+	canonical       string
+	headerCanonical string
+}
+
+// next returns the address a search engine would move on to from
+// p: its redirect target, or else its canonical, if it is not
+// self-referencing.
+func (p *canonicalPage) next(addr string) string {
+	if p.redirect != "" && p.redirect != addr {
+		return p.redirect
+	}
+	if p.canonical != addr {
+		return p.canonical
+	}
+	return ""
+}
+
+// CanonicalAnalyzer accumulates the canonicals, status codes, and
+// robots directives of crawled pages, and resolves canonical chains.
+type CanonicalAnalyzer struct {
+	pages map[string]*canonicalPage
+}
+
+func NewCanonicalAnalyzer() *CanonicalAnalyzer {
+	return &CanonicalAnalyzer{
+		pages: make(map[string]*canonicalPage),
+	}
+}
+
+// Add records the information about r needed to analyze canonicals.
+func (a *CanonicalAnalyzer) Add(r *data.Result) {
+	if r.Address == nil || r.Resource {
+		return
+	}
+	p := &canonicalPage{
+		status: r.StatusCode,
+		noindex: r.IndexabilityReason == data.ReasonNoindexMeta ||
+			r.IndexabilityReason == data.ReasonNoindexHeader,
+	}
+	if r.StatusCode >= 300 && r.StatusCode < 400 && r.ResolvesTo != nil {
+		p.redirect = r.ResolvesTo.Full
+	}
+	if r.HeaderCanonical != nil && r.HeaderCanonical.Address != nil {
+		p.headerCanonical = r.HeaderCanonical.Address.Full
+	}
+	// A page without a canonical tag has a Canonical with an
+	// empty Href.
+	if r.Canonical != nil && r.Canonical.Href != "" && r.Canonical.Address != nil {
+		p.canonical = r.Canonical.Address.Full
+	} else {
+		p.canonical = p.headerCanonical
+	}
+	if p.canonical == "" {
+		p.canonical = r.Address.Full
+	}
+	a.pages[r.Address.Full] = p
+}
+
+// Findings returns a CanonicalFinding for every page with a problem
+// with its canonical, ordered by address.
+func (a *CanonicalAnalyzer) Findings() (findings []*CanonicalFinding) {
+	var addrs []string
+	for addr := range a.pages {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	for _, addr := range addrs {
+		if f := a.check(addr); f != nil {
+			findings = append(findings, f)
+		}
+	}
+	return
+}
+
+// check returns the finding for the page at addr, or nil if there is
+// no problem.
+func (a *CanonicalAnalyzer) check(addr string) *CanonicalFinding {
+	p := a.pages[addr]
+	f := &CanonicalFinding{
+		Address:         addr,
+		Canonical:       p.canonical,
+		HeaderCanonical: p.headerCanonical,
+	}
+	if p.headerCanonical != "" && p.headerCanonical != p.canonical {
+		f.Issues = append(f.Issues, CanonicalConflict)
+	}
+
+	// Pages that redirect, or that are their own canonical, are
+	// not themselves canonicalized.
+	if p.redirect != "" || p.canonical == addr {
+		return f.orNil()
+	}
+
+	target, ok := a.pages[p.canonical]
+	switch {
+	case !ok:
+		f.Issues = append(f.Issues, CanonicalNotCrawled)
+	case target.redirect != "":
+		f.Issues = append(f.Issues, CanonicalRedirects)
+	case target.status != 200:
+		f.Issues = append(f.Issues, CanonicalNot200)
+	case target.noindex:
+		f.Issues = append(f.Issues, CanonicalNoindex)
+	}
+
+	// Follow the chain from the page until it stops or loops.
+	f.Chain = []string{addr}
+	seen := map[string]bool{addr: true}
+	for cur := p.canonical; cur != ""; {
+		f.Chain = append(f.Chain, cur)
+		if seen[cur] {
+			f.Issues = append(f.Issues, CanonicalLoop)
+			break
+		}
+		seen[cur] = true
+		f.Final = cur
+		next, ok := a.pages[cur]
+		if !ok {
+			break
+		}
+		cur = next.next(cur)
+	}
+	if len(f.Chain) > 2 && !hasIssue(f.Issues, CanonicalLoop) {
+		f.Issues = append(f.Issues, CanonicalChain)
+	}
+	if hasIssue(f.Issues, CanonicalLoop) {
+		f.Final = ""
+	}
+	return f.orNil()
+}
+
+// orNil returns f if it has any issues, otherwise nil.
+func (f *CanonicalFinding) orNil() *CanonicalFinding {
+	if len(f.Issues) == 0 {
+		return nil
+	}
+	return f
+}
+
+func hasIssue(issues []string, issue string) bool {
+	for _, i := range issues {
+		if i == issue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+func canonicalResult(addr string, status int, canonical string) *data.Result {
+	r := &data.Result{
+		Address:    data.MakeAddress(addr),
+		StatusCode: status,
+	}
+	r.Canonical = data.MakeCanonical(r.Address, canonical)
+	return r
+}
+
+func TestCanonicalAnalyzer(t *testing.T) {
+	a := NewCanonicalAnalyzer()
+
+	// /a -> /b -> /c is a chain; /c is fine.
+	a.Add(canonicalResult("https://example.com/a", 200, "/b"))
+	a.Add(canonicalResult("https://example.com/b", 200, "/c"))
+	a.Add(canonicalResult("https://example.com/c", 200, ""))
+
+	// /d -> /e, which redirects to /c.
+	a.Add(canonicalResult("https://example.com/d", 200, "/e"))
+	e := canonicalResult("https://example.com/e", 301, "")
+	e.ResolvesTo = data.MakeAddress("https://example.com/c")
+	a.Add(e)
+
+	// /f -> /g -> /f is a loop.
+	a.Add(canonicalResult("https://example.com/f", 200, "/g"))
+	a.Add(canonicalResult("https://example.com/g", 200, "/f"))
+
+	// /h -> /i, which is noindexed.
+	a.Add(canonicalResult("https://example.com/h", 200, "/i"))
+	i := canonicalResult("https://example.com/i", 200, "")
+	i.IndexabilityReason = data.ReasonNoindexMeta
+	a.Add(i)
+
+	// /j has conflicting HTML and header canonicals.
+	j := canonicalResult("https://example.com/j", 200, "/j")
+	j.HeaderCanonical = data.MakeCanonical(j.Address, "/c")
+	a.Add(j)
+
+	want := map[string]string{
+		"https://example.com/a": "canonical_chain",
+		"https://example.com/b": "",
+		"https://example.com/d": "canonical_redirects canonical_chain",
+		"https://example.com/f": "canonical_loop",
+		"https://example.com/g": "canonical_loop",
+		"https://example.com/h": "canonical_noindex",
+		"https://example.com/j": "header_conflict",
+	}
+
+	got := make(map[string]string)
+	for _, f := range a.Findings() {
+		got[f.Address] = strings.Join(f.Issues, " ")
+	}
+	for addr, issues := range want {
+		if got[addr] != issues {
+			t.Errorf("%s: expected issues %q, got %q", addr, issues, got[addr])
+		}
+	}
+	if len(got) != 6 {
+		t.Errorf("expected 6 findings, got %d: %v", len(got), got)
+	}
+}
@@ -0,0 +1,126 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+	"github.com/benjaminestes/crawl/fingerprint"
+)
+
+// DuplicateCluster is a group of pages whose SimHashes are within
+// the threshold of a DuplicateFinder of each other, directly or
+// through other pages in the cluster. MaxDistance is the greatest
+// distance between any two pages that were matched directly.
+type DuplicateCluster struct {
+	Size        int
+	MaxDistance int
+	Addresses   []string
+}
+
+// DuplicateFinder accumulates the SimHashes of crawled pages and
+// groups them into clusters of near-duplicates. Only the address and
+// SimHash of each page are retained, so it can handle large crawls.
+type DuplicateFinder struct {
+	threshold int
+	addrs     []string
+	hashes    []uint64
+}
+
+// NewDuplicateFinder returns a DuplicateFinder that considers pages
+// to be near-duplicates if their SimHashes differ in at most
+// threshold bits. Threshold must be between 0 and 63.
+func NewDuplicateFinder(threshold int) *DuplicateFinder {
+	return &DuplicateFinder{threshold: threshold}
+}
+
+// Add records the SimHash of r, if it is a successfully crawled page
+// with text.
+func (f *DuplicateFinder) Add(r *data.Result) {
+	if r.Address == nil || r.StatusCode != 200 || r.SimHash == 0 {
+		return
+	}
+	f.addrs = append(f.addrs, r.Address.Full)
+	f.hashes = append(f.hashes, uint64(r.SimHash))
+}
+
+// Clusters returns every cluster of two or more near-duplicate pages,
+// largest first.
+//
+// Rather than compare every pair of pages, the 64 bits of each hash
+// are divided into threshold+1 blocks. Two hashes differing in at
+// most threshold bits must be identical in at least one block, so
+// only pages that share a block are compared.
+func (f *DuplicateFinder) Clusters() []*DuplicateCluster {
+	parent := make([]int, len(f.hashes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	maxDistance := make(map[int]int)
+	blocks := f.threshold + 1
+	for b := 0; b < blocks; b++ {
+		lo, hi := 64*b/blocks, 64*(b+1)/blocks
+		mask := (^uint64(0) >> uint(64-(hi-lo))) << uint(lo)
+
+		buckets := make(map[uint64][]int)
+		for i, h := range f.hashes {
+			buckets[h&mask] = append(buckets[h&mask], i)
+		}
+		for _, bucket := range buckets {
+			for x := 0; x < len(bucket); x++ {
+				for y := x + 1; y < len(bucket); y++ {
+					i, j := bucket[x], bucket[y]
+					d := fingerprint.Distance(f.hashes[i], f.hashes[j])
+					if d > f.threshold {
+						continue
+					}
+					ri, rj := find(i), find(j)
+					if ri != rj {
+						parent[rj] = ri
+						if maxDistance[rj] > maxDistance[ri] {
+							maxDistance[ri] = maxDistance[rj]
+						}
+					}
+					if d > maxDistance[ri] {
+						maxDistance[ri] = d
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[int]*DuplicateCluster)
+	for i := range f.hashes {
+		root := find(i)
+		c, ok := groups[root]
+		if !ok {
+			c = &DuplicateCluster{MaxDistance: maxDistance[root]}
+			groups[root] = c
+		}
+		c.Addresses = append(c.Addresses, f.addrs[i])
+		c.Size++
+	}
+
+	var clusters []*DuplicateCluster
+	for _, c := range groups {
+		if c.Size < 2 {
+			continue
+		}
+		sort.Strings(c.Addresses)
+		clusters = append(clusters, c)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Size != clusters[j].Size {
+			return clusters[i].Size > clusters[j].Size
+		}
+		return clusters[i].Addresses[0] < clusters[j].Addresses[0]
+	})
+	return clusters
+}
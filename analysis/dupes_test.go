@@ -0,0 +1,58 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+func result(addr string, simhash uint64) *data.Result {
+	return &data.Result{
+		Address:    data.MakeAddress(addr),
+		StatusCode: 200,
+		SimHash:    int64(simhash),
+	}
+}
+
+func TestDuplicateFinder(t *testing.T) {
+	f := NewDuplicateFinder(3)
+	f.Add(result("https://example.com/a", 0xF0F0F0F0F0F0F0F0))
+	f.Add(result("https://example.com/b", 0xF0F0F0F0F0F0F0F1))
+	f.Add(result("https://example.com/c", 0xF0F0F0F0F0F0F0F7)) // 2 bits from b
+	f.Add(result("https://example.com/d", 0x0F0F0F0F0F0F0F0F))
+	f.Add(result("https://example.com/e", 0x0F0F0F0F0F0F0F0E))
+	f.Add(result("https://example.com/f", 0x123456789ABCDEF0))
+	f.Add(&data.Result{Address: data.MakeAddress("https://example.com/g"), StatusCode: 404})
+
+	clusters := f.Clusters()
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %d", len(clusters))
+	}
+
+	got := strings.Join(clusters[0].Addresses, " ")
+	want := "https://example.com/a https://example.com/b https://example.com/c"
+	if got != want || clusters[0].MaxDistance != 3 {
+		t.Errorf("unexpected first cluster: %+v", clusters[0])
+	}
+	if clusters[1].Size != 2 || clusters[1].MaxDistance != 1 {
+		t.Errorf("unexpected second cluster: %+v", clusters[1])
+	}
+}
+
+func TestReadResults(t *testing.T) {
+	in := strings.NewReader(`{"Address":{"Full":"https://example.com/"},"Depth":0}
+{"Address":{"Full":"https://example.com/a"},"Depth":1}
+`)
+	var depths []int
+	err := ReadResults(in, func(r *data.Result) error {
+		depths = append(depths, r.Depth)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(depths) != 2 || depths[1] != 1 {
+		t.Errorf("unexpected results: %v", depths)
+	}
+}
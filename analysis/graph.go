@@ -0,0 +1,441 @@
+package analysis
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// GraphNode is a page in a link graph. Pages that were linked to but
+// not crawled, such as external pages, have Crawled false and no
+// status, depth, or title.
+type GraphNode struct {
+	Address    string
+	Crawled    bool
+	StatusCode int
+	Depth      int
+	Title      string
+}
+
+// GraphEdge is a hyperlink from the page at Source to the page at
+// Target, or, if Redirect is true, a redirect from a page with a 3xx
+// status to its target.
+type GraphEdge struct {
+	Source   string
+	Target   string
+	Anchor   string
+	Nofollow bool
+	Redirect bool
+}
+
+// Graph is the link graph of a crawl. Nodes are ordered by address,
+// and edges by source, then by their order on the page.
+type Graph struct {
+	Nodes []*GraphNode
+	Edges []*GraphEdge
+}
+
+// graphPage is what a GraphBuilder retains about a crawled page.
+type graphPage struct {
+	node     *GraphNode
+	host     string
+	redirect string
+	links    []*GraphEdge
+}
+
+// GraphBuilder accumulates the pages and hyperlinks of a crawl, and
+// builds its link graph.
+type GraphBuilder struct {
+	internalOnly      bool
+	collapseRedirects bool
+	pages             map[string]*graphPage
+}
+
+// NewGraphBuilder returns a GraphBuilder. If internalOnly is true,
+// only links to pages on the same host as the linking page are
+// included. If collapseRedirects is true, links to pages that
+// redirect point instead to the end of the redirect chain, and pages
+// that redirect are left out of the graph. Otherwise, each page that
+// redirects has a redirect edge to its target.
+func NewGraphBuilder(internalOnly, collapseRedirects bool) *GraphBuilder {
+	return &GraphBuilder{
+		internalOnly:      internalOnly,
+		collapseRedirects: collapseRedirects,
+		pages:             make(map[string]*graphPage),
+	}
+}
+
+// Add records the page r and its hyperlinks.
+func (b *GraphBuilder) Add(r *data.Result) {
+	if r.Address == nil || r.Resource {
+		return
+	}
+	p := &graphPage{
+		node: &GraphNode{
+			Address:    r.Address.Full,
+			Crawled:    true,
+			StatusCode: r.StatusCode,
+			Depth:      r.Depth,
+			Title:      r.Title,
+		},
+		host: r.Address.Host,
+	}
+	if r.StatusCode >= 300 && r.StatusCode < 400 && r.ResolvesTo != nil {
+		p.redirect = r.ResolvesTo.Full
+	}
+	for _, l := range r.Links {
+		if !l.IsHyperlink() || l.Address == nil {
+			continue
+		}
+		p.links = append(p.links, &GraphEdge{
+			Source:   r.Address.Full,
+			Target:   l.Address.Full,
+			Anchor:   l.Anchor,
+			Nofollow: l.Nofollow,
+		})
+	}
+	b.pages[r.Address.Full] = p
+}
+
+// resolve returns the address at the end of the redirect chain
+// starting at addr, or addr itself if the chain loops.
+func (b *GraphBuilder) resolve(addr string) string {
+	seen := map[string]bool{addr: true}
+	for cur := addr; ; {
+		p, ok := b.pages[cur]
+		if !ok || p.redirect == "" {
+			return cur
+		}
+		if seen[p.redirect] {
+			return addr
+		}
+		seen[p.redirect] = true
+		cur = p.redirect
+	}
+}
+
+// Graph returns the link graph of the pages added so far.
+func (b *GraphBuilder) Graph() *Graph {
+	var addrs []string
+	for addr := range b.pages {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	g := &Graph{}
+	nodes := make(map[string]*GraphNode)
+	addNode := func(addr string) {
+		if _, ok := nodes[addr]; ok {
+			return
+		}
+		n := &GraphNode{Address: addr}
+		if p, ok := b.pages[addr]; ok {
+			n = p.node
+		}
+		nodes[addr] = n
+	}
+
+	for _, addr := range addrs {
+		p := b.pages[addr]
+		if b.collapseRedirects && b.resolve(addr) != addr {
+			continue
+		}
+		addNode(addr)
+		addEdge := func(e *GraphEdge) {
+			if b.internalOnly && hostOf(e.Target, b.pages) != p.host {
+				return
+			}
+			addNode(e.Target)
+			g.Edges = append(g.Edges, e)
+		}
+		if !b.collapseRedirects && p.redirect != "" {
+			addEdge(&GraphEdge{Source: addr, Target: p.redirect, Redirect: true})
+		}
+		for _, l := range p.links {
+			e := *l
+			if b.collapseRedirects {
+				e.Target = b.resolve(e.Target)
+			}
+			addEdge(&e)
+		}
+	}
+
+	for _, n := range nodes {
+		g.Nodes = append(g.Nodes, n)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].Address < g.Nodes[j].Address
+	})
+	return g
+}
+
+// hostOf returns the host of addr, using what is known of the page
+// if it was crawled.
+func hostOf(addr string, pages map[string]*graphPage) string {
+	if p, ok := pages[addr]; ok {
+		return p.host
+	}
+	if a := data.MakeAddress(addr); a != nil {
+		return a.Host
+	}
+	return ""
+}
+
+// Keys of the attributes of nodes and edges, in the order they are
+// declared in GraphML and GEXF.
+var (
+	graphNodeKeys = []graphKey{
+		{"crawled", "boolean"},
+		{"status", "int"},
+		{"depth", "int"},
+		{"title", "string"},
+	}
+	graphEdgeKeys = []graphKey{
+		{"anchor", "string"},
+		{"nofollow", "boolean"},
+		{"redirect", "boolean"},
+	}
+)
+
+type graphKey struct {
+	name, typ string
+}
+
+// attributes returns the values of the attributes of n, in the order
+// of graphNodeKeys. Attributes without a value are empty.
+func (n *GraphNode) attributes() []string {
+	if !n.Crawled {
+		return []string{"false", "", "", ""}
+	}
+	return []string{
+		"true",
+		strconv.Itoa(n.StatusCode),
+		strconv.Itoa(n.Depth),
+		n.Title,
+	}
+}
+
+// attributes returns the values of the attributes of e, in the order
+// of graphEdgeKeys.
+func (e *GraphEdge) attributes() []string {
+	return []string{e.Anchor, strconv.FormatBool(e.Nofollow), strconv.FormatBool(e.Redirect)}
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// makeGraphMLData returns the data elements for values, keyed by the
+// names of keys. Empty values are left out.
+func makeGraphMLData(keys []graphKey, values []string) (d []graphMLData) {
+	for i, v := range values {
+		if v != "" {
+			d = append(d, graphMLData{Key: keys[i].name, Value: v})
+		}
+	}
+	return
+}
+
+// WriteGraphML writes g to w as a directed GraphML graph. Nodes are
+// identified by their address.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Graph: graphMLGraph{ID: "crawl", EdgeDefault: "directed"},
+	}
+	for _, k := range graphNodeKeys {
+		doc.Keys = append(doc.Keys, graphMLKey{k.name, "node", k.name, k.typ})
+	}
+	for _, k := range graphEdgeKeys {
+		doc.Keys = append(doc.Keys, graphMLKey{k.name, "edge", k.name, k.typ})
+	}
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID:   n.Address,
+			Data: makeGraphMLData(graphNodeKeys, n.attributes()),
+		})
+	}
+	for i, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			ID:     "e" + strconv.Itoa(i),
+			Source: e.Source,
+			Target: e.Target,
+			Data:   makeGraphMLData(graphEdgeKeys, e.attributes()),
+		})
+	}
+	return writeXML(w, doc)
+}
+
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Creator string    `xml:"meta>creator"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode       `xml:"nodes>node"`
+	Edges           []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// makeGEXFAttributes declares the attributes named by keys.
+func makeGEXFAttributes(class string, keys []graphKey) gexfAttributes {
+	a := gexfAttributes{Class: class}
+	for _, k := range keys {
+		typ := k.typ
+		if typ == "int" {
+			typ = "integer"
+		}
+		a.Attributes = append(a.Attributes, gexfAttribute{k.name, k.name, typ})
+	}
+	return a
+}
+
+// makeGEXFAttValues returns the values of attributes named by keys.
+// Empty values are left out.
+func makeGEXFAttValues(keys []graphKey, values []string) (v []gexfAttValue) {
+	for i, value := range values {
+		if value != "" {
+			v = append(v, gexfAttValue{keys[i].name, value})
+		}
+	}
+	return
+}
+
+// WriteGEXF writes g to w as a directed GEXF 1.2 graph. Nodes are
+// identified by their address, and labeled with their title if they
+// have one.
+func (g *Graph) WriteGEXF(w io.Writer) error {
+	doc := gexfDocument{
+		XMLNS:   "http://www.gexf.net/1.2draft",
+		Version: "1.2",
+		Creator: "crawl",
+		Graph: gexfGraph{
+			DefaultEdgeType: "directed",
+			Attributes: []gexfAttributes{
+				makeGEXFAttributes("node", graphNodeKeys),
+				makeGEXFAttributes("edge", graphEdgeKeys),
+			},
+		},
+	}
+	for _, n := range g.Nodes {
+		label := n.Title
+		if label == "" {
+			label = n.Address
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+			ID:        n.Address,
+			Label:     label,
+			AttValues: makeGEXFAttValues(graphNodeKeys, n.attributes()),
+		})
+	}
+	for i, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+			ID:        strconv.Itoa(i),
+			Source:    e.Source,
+			Target:    e.Target,
+			AttValues: makeGEXFAttValues(graphEdgeKeys, e.attributes()),
+		})
+	}
+	return writeXML(w, doc)
+}
+
+// writeXML writes doc to w as an indented XML document.
+func writeXML(w io.Writer, doc interface{}) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(bw)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	if err := bw.WriteByte('\n'); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// WriteEdgeCSV writes the edges of g to w as CSV, with a header row
+// of source, target, anchor, nofollow, and redirect.
+func (g *Graph) WriteEdgeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"source", "target", "anchor", "nofollow", "redirect"})
+	for _, e := range g.Edges {
+		cw.Write(append([]string{e.Source, e.Target}, e.attributes()...))
+	}
+	cw.Flush()
+	return cw.Error()
+}
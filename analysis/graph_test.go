@@ -0,0 +1,188 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+func graphResult(addr string, status int, title string, hrefs ...string) *data.Result {
+	r := &data.Result{
+		Address:    data.MakeAddress(addr),
+		StatusCode: status,
+		Depth:      1,
+		Title:      title,
+	}
+	for _, href := range hrefs {
+		l := data.MakeLink(r.Address, href, "anchor "+href, "")
+		l.Element = "a"
+		r.Links = append(r.Links, l)
+	}
+	return r
+}
+
+// graphFixture is a crawl in which / links to /a, to /old, which
+// redirects to /new, and to an external page.
+func graphFixture(b *GraphBuilder) {
+	b.Add(graphResult("https://example.com/", 200, "Home",
+		"/a", "/old", "https://other.com/x"))
+	b.Add(graphResult("https://example.com/a", 200, "A", "/"))
+	old := graphResult("https://example.com/old", 301, "")
+	old.ResolvesTo = data.MakeAddress("https://example.com/new")
+	b.Add(old)
+	b.Add(graphResult("https://example.com/new", 200, "New"))
+
+	// Resources are left out of the graph.
+	b.Add(&data.Result{
+		Address:  data.MakeAddress("https://example.com/img.png"),
+		Resource: true,
+	})
+}
+
+func edgeList(g *Graph) string {
+	var edges []string
+	for _, e := range g.Edges {
+		edge := e.Source + " " + e.Target
+		if e.Redirect {
+			edge += " redirect"
+		}
+		edges = append(edges, edge)
+	}
+	return strings.Join(edges, "\n")
+}
+
+func nodeList(g *Graph) string {
+	var nodes []string
+	for _, n := range g.Nodes {
+		nodes = append(nodes, fmt.Sprintf("%s %v %d", n.Address, n.Crawled, n.StatusCode))
+	}
+	return strings.Join(nodes, "\n")
+}
+
+func TestGraphBuilder(t *testing.T) {
+	tests := []struct {
+		name                 string
+		internal, collapse   bool
+		wantNodes, wantEdges string
+	}{
+		{
+			name: "all",
+			wantNodes: `https://example.com/ true 200
+https://example.com/a true 200
+https://example.com/new true 200
+https://example.com/old true 301
+https://other.com/x false 0`,
+			wantEdges: `https://example.com/ https://example.com/a
+https://example.com/ https://example.com/old
+https://example.com/ https://other.com/x
+https://example.com/a https://example.com/
+https://example.com/old https://example.com/new redirect`,
+		},
+		{
+			name:     "internal, collapsed",
+			internal: true,
+			collapse: true,
+			wantNodes: `https://example.com/ true 200
+https://example.com/a true 200
+https://example.com/new true 200`,
+			wantEdges: `https://example.com/ https://example.com/a
+https://example.com/ https://example.com/new
+https://example.com/a https://example.com/`,
+		},
+	}
+
+	for _, test := range tests {
+		b := NewGraphBuilder(test.internal, test.collapse)
+		graphFixture(b)
+		g := b.Graph()
+		if got := nodeList(g); got != test.wantNodes {
+			t.Errorf("%s: nodes:\n%s\nwant:\n%s", test.name, got, test.wantNodes)
+		}
+		if got := edgeList(g); got != test.wantEdges {
+			t.Errorf("%s: edges:\n%s\nwant:\n%s", test.name, got, test.wantEdges)
+		}
+	}
+}
+
+func TestGraphRedirectLoop(t *testing.T) {
+	b := NewGraphBuilder(false, true)
+	b.Add(graphResult("https://example.com/", 200, "", "/a"))
+	a := graphResult("https://example.com/a", 301, "")
+	a.ResolvesTo = data.MakeAddress("https://example.com/b")
+	b.Add(a)
+	c := graphResult("https://example.com/b", 301, "")
+	c.ResolvesTo = data.MakeAddress("https://example.com/a")
+	b.Add(c)
+
+	want := "https://example.com/ https://example.com/a"
+	if got := edgeList(b.Graph()); got != want {
+		t.Errorf("edges: %s, want %s", got, want)
+	}
+}
+
+func TestGraphWriters(t *testing.T) {
+	b := NewGraphBuilder(false, false)
+	graphFixture(b)
+	g := b.Graph()
+
+	var buf bytes.Buffer
+	if err := g.WriteGraphML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var graphml graphMLDocument
+	if err := xml.Unmarshal(buf.Bytes(), &graphml); err != nil {
+		t.Fatalf("couldn't parse GraphML: %v", err)
+	}
+	if len(graphml.Graph.Nodes) != len(g.Nodes) || len(graphml.Graph.Edges) != len(g.Edges) {
+		t.Errorf("GraphML has %d nodes and %d edges, want %d and %d",
+			len(graphml.Graph.Nodes), len(graphml.Graph.Edges), len(g.Nodes), len(g.Edges))
+	}
+	home := graphml.Graph.Nodes[0]
+	want := []graphMLData{
+		{"crawled", "true"}, {"status", "200"}, {"depth", "1"}, {"title", "Home"},
+	}
+	if fmt.Sprint(home.Data) != fmt.Sprint(want) {
+		t.Errorf("GraphML node data: %v, want %v", home.Data, want)
+	}
+
+	buf.Reset()
+	if err := g.WriteGEXF(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var gexf gexfDocument
+	if err := xml.Unmarshal(buf.Bytes(), &gexf); err != nil {
+		t.Fatalf("couldn't parse GEXF: %v", err)
+	}
+	if len(gexf.Graph.Nodes) != len(g.Nodes) || len(gexf.Graph.Edges) != len(g.Edges) {
+		t.Errorf("GEXF has %d nodes and %d edges, want %d and %d",
+			len(gexf.Graph.Nodes), len(gexf.Graph.Edges), len(g.Nodes), len(g.Edges))
+	}
+	if label := gexf.Graph.Nodes[len(gexf.Graph.Nodes)-1].Label; label != "https://other.com/x" {
+		t.Errorf("GEXF label of untitled node: %q", label)
+	}
+
+	buf.Reset()
+	if err := g.WriteEdgeCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(g.Edges)+1 {
+		t.Fatalf("CSV has %d records, want %d", len(records), len(g.Edges)+1)
+	}
+	wantRecord := "[https://example.com/ https://example.com/a anchor /a false false]"
+	if got := fmt.Sprint(records[1]); got != wantRecord {
+		t.Errorf("CSV record: %s, want %s", got, wantRecord)
+	}
+	wantRecord = "[https://example.com/old https://example.com/new  false true]"
+	if got := fmt.Sprint(records[len(records)-1]); got != wantRecord {
+		t.Errorf("CSV redirect record: %s, want %s", got, wantRecord)
+	}
+}
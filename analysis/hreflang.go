@@ -0,0 +1,309 @@
+package analysis
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+	"golang.org/x/text/language"
+)
+
+// Kinds of HreflangFinding.
+const (
+	HreflangMissingReturnLink    = "missing_return_link"
+	HreflangMissingSelfReference = "missing_self_reference"
+	HreflangInvalidCode          = "invalid_code"
+	HreflangMultipleXDefault     = "multiple_x_default"
+	HreflangMissingXDefault      = "missing_x_default"
+	HreflangTargetNotCrawled     = "target_not_crawled"
+	HreflangTargetNot200         = "target_not_200"
+	HreflangTargetNotCanonical   = "target_not_canonical"
+	HreflangConflict             = "conflicting_annotations"
+)
+
+// HreflangFinding is a single problem with the hreflang annotations
+// of a crawl. Cluster identifies the group of pages connected by
+// hreflang annotations that the problem belongs to; it is the first
+// address of the cluster in sort order. Address is the page with the
+// problem, and Target and Hreflang describe the annotation at fault,
+// if any.
+type HreflangFinding struct {
+	Cluster  string
+	Issue    string
+	Address  string
+	Target   string `json:",omitempty"`
+	Hreflang string `json:",omitempty"`
+	Detail   string `json:",omitempty"`
+}
+
+// hreflangPage is what a HreflangValidator retains about a page.
+type hreflangPage struct {
+	crawled       bool
+	status        int
+	canonicalized bool
+	annotations   []hreflangAnnotation
+}
+
+type hreflangAnnotation struct {
+	code   string
+	target string
+}
+
+// HreflangValidator accumulates the hreflang annotations of crawled
+// pages, both from HTML and from Link headers, and checks them for
+// problems.
+type HreflangValidator struct {
+	pages map[string]*hreflangPage
+}
+
+func NewHreflangValidator() *HreflangValidator {
+	return &HreflangValidator{
+		pages: make(map[string]*hreflangPage),
+	}
+}
+
+func (v *HreflangValidator) page(addr string) *hreflangPage {
+	p, ok := v.pages[addr]
+	if !ok {
+		p = &hreflangPage{}
+		v.pages[addr] = p
+	}
+	return p
+}
+
+// Add records the status and hreflang annotations of r.
+func (v *HreflangValidator) Add(r *data.Result) {
+	if r.Address == nil || r.Resource {
+		return
+	}
+	p := v.page(r.Address.Full)
+	p.crawled = true
+	p.status = r.StatusCode
+	p.canonicalized = r.IndexabilityReason == data.ReasonCanonicalizedHTML ||
+		r.IndexabilityReason == data.ReasonCanonicalizedHeader
+
+	for _, h := range append(r.Hreflang, r.HeaderHreflang...) {
+		if h.Address == nil {
+			continue
+		}
+		p.annotations = append(p.annotations, hreflangAnnotation{
+			code:   strings.ToLower(strings.TrimSpace(h.Hreflang)),
+			target: h.Address.Full,
+		})
+	}
+}
+
+// Findings checks every cluster of pages connected by hreflang
+// annotations, and returns the problems found, ordered by cluster.
+func (v *HreflangValidator) Findings() (findings []*HreflangFinding) {
+	clusters := v.clusters()
+	for _, cluster := range clusters {
+		findings = append(findings, v.checkCluster(cluster)...)
+	}
+	return
+}
+
+// clusters groups the pages with annotations, and the targets of
+// those annotations, into connected components. Each cluster is
+// sorted, and clusters are ordered by their first address.
+func (v *HreflangValidator) clusters() [][]string {
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(a string) string {
+		if _, ok := parent[a]; !ok {
+			parent[a] = a
+		}
+		if parent[a] != a {
+			parent[a] = find(parent[a])
+		}
+		return parent[a]
+	}
+	for addr, p := range v.pages {
+		for _, a := range p.annotations {
+			ra, rb := find(addr), find(a.target)
+			if ra != rb {
+				parent[rb] = ra
+			}
+		}
+	}
+
+	groups := make(map[string][]string)
+	for addr := range parent {
+		root := find(addr)
+		groups[root] = append(groups[root], addr)
+	}
+	var clusters [][]string
+	for _, g := range groups {
+		sort.Strings(g)
+		clusters = append(clusters, g)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i][0] < clusters[j][0]
+	})
+	return clusters
+}
+
+func (v *HreflangValidator) checkCluster(cluster []string) (findings []*HreflangFinding) {
+	report := func(issue, addr string, a hreflangAnnotation, detail string) {
+		findings = append(findings, &HreflangFinding{
+			Cluster:  cluster[0],
+			Issue:    issue,
+			Address:  addr,
+			Target:   a.target,
+			Hreflang: a.code,
+			Detail:   detail,
+		})
+	}
+
+	// codesForTarget records the codes each target is given
+	// anywhere in the cluster, to find conflicts between pages.
+	codesForTarget := make(map[string]map[string]bool)
+	var hasXDefault bool
+	var annotated []string
+
+	for _, addr := range cluster {
+		p := v.pages[addr]
+		if p == nil || len(p.annotations) == 0 {
+			continue
+		}
+		annotated = append(annotated, addr)
+
+		var self bool
+		targetsForCode := make(map[string]map[string]bool)
+		for _, a := range p.annotations {
+			if a.target == addr {
+				self = true
+			}
+			if codesForTarget[a.target] == nil {
+				codesForTarget[a.target] = make(map[string]bool)
+			}
+			codesForTarget[a.target][a.code] = true
+			if targetsForCode[a.code] == nil {
+				targetsForCode[a.code] = make(map[string]bool)
+			}
+			targetsForCode[a.code][a.target] = true
+
+			if a.code == "x-default" {
+				hasXDefault = true
+			} else if !ValidHreflang(a.code) {
+				report(HreflangInvalidCode, addr, a, "")
+			}
+
+			t := v.pages[a.target]
+			switch {
+			case t == nil || !t.crawled:
+				report(HreflangTargetNotCrawled, addr, a, "")
+			case t.status != 200:
+				report(HreflangTargetNot200, addr, a, statusDetail(t.status))
+			case t.canonicalized:
+				report(HreflangTargetNotCanonical, addr, a, "")
+			case a.target != addr && !t.annotates(addr):
+				report(HreflangMissingReturnLink, addr, a, "")
+			}
+		}
+
+		if !self {
+			report(HreflangMissingSelfReference, addr, hreflangAnnotation{}, "")
+		}
+		var codes []string
+		for code := range targetsForCode {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			targets := targetsForCode[code]
+			if len(targets) < 2 {
+				continue
+			}
+			issue := HreflangConflict
+			if code == "x-default" {
+				issue = HreflangMultipleXDefault
+			}
+			report(issue, addr, hreflangAnnotation{code: code},
+				"annotated with "+strings.Join(sortedKeys(targets), ", "))
+		}
+	}
+
+	var targets []string
+	for target := range codesForTarget {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		codes := codesForTarget[target]
+		delete(codes, "x-default")
+		if len(codes) > 1 {
+			report(HreflangConflict, target, hreflangAnnotation{},
+				"given codes "+strings.Join(sortedKeys(codes), ", "))
+		}
+	}
+
+	if !hasXDefault && len(annotated) > 0 {
+		report(HreflangMissingXDefault, annotated[0], hreflangAnnotation{},
+			"no page in the cluster declares x-default")
+	}
+	return
+}
+
+// annotates reports whether p has an annotation targeting addr.
+func (p *hreflangPage) annotates(addr string) bool {
+	for _, a := range p.annotations {
+		if a.target == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// statusDetail describes an HTTP status code for a finding.
+func statusDetail(status int) string {
+	if status == 0 {
+		return "no response"
+	}
+	return "status " + strconv.Itoa(status)
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidHreflang reports whether code is a valid hreflang value other
+// than x-default: an ISO 639-1 language code, optionally followed by
+// an ISO 15924 script code and an ISO 3166-1 alpha-2 region code.
+func ValidHreflang(code string) bool {
+	parts := strings.Split(code, "-")
+	if len(parts[0]) != 2 {
+		return false
+	}
+	if _, err := language.ParseBase(parts[0]); err != nil {
+		return false
+	}
+	parts = parts[1:]
+	if len(parts) > 0 && len(parts[0]) == 4 {
+		if _, err := language.ParseScript(parts[0]); err != nil {
+			return false
+		}
+		parts = parts[1:]
+	}
+	switch len(parts) {
+	case 0:
+		return true
+	case 1:
+		// UK is reserved for the United Kingdom, but isn't its
+		// ISO 3166-1 code, which is GB.
+		if len(parts[0]) != 2 || strings.EqualFold(parts[0], "uk") {
+			return false
+		}
+		r, err := language.ParseRegion(parts[0])
+		return err == nil && r.IsCountry()
+	default:
+		return false
+	}
+}
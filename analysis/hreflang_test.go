@@ -0,0 +1,71 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+func hreflangResult(addr string, status int, annotations ...string) *data.Result {
+	r := &data.Result{
+		Address:    data.MakeAddress(addr),
+		StatusCode: status,
+	}
+	for i := 0; i+1 < len(annotations); i += 2 {
+		r.Hreflang = append(r.Hreflang, data.MakeHreflang(r.Address, annotations[i+1], annotations[i]))
+	}
+	return r
+}
+
+func TestHreflangValidator(t *testing.T) {
+	v := NewHreflangValidator()
+	v.Add(hreflangResult("https://example.com/en", 200,
+		"en", "/en", "de", "/de", "fr-UK", "/fr", "x-default", "/en"))
+	v.Add(hreflangResult("https://example.com/de", 200,
+		"de", "/de", "en", "/en", "en", "/other"))
+	v.Add(hreflangResult("https://example.com/fr", 404))
+	v.Add(hreflangResult("https://example.com/other", 200))
+
+	want := map[string]int{
+		HreflangInvalidCode:          1, // fr-UK
+		HreflangTargetNot200:         1, // /fr
+		HreflangMissingReturnLink:    1, // /other doesn't link back to /de
+		HreflangConflict:             1, // en on /de points to two targets
+		HreflangMissingSelfReference: 0,
+		HreflangMissingXDefault:      0,
+	}
+
+	got := make(map[string]int)
+	findings := v.Findings()
+	for _, f := range findings {
+		got[f.Issue]++
+		if f.Cluster != "https://example.com/de" {
+			t.Errorf("expected a single cluster, got %s", f.Cluster)
+		}
+	}
+	for issue, n := range want {
+		if got[issue] != n {
+			t.Errorf("expected %d %s findings, got %d: %+v", n, issue, got[issue], findings)
+		}
+	}
+}
+
+func TestValidHreflang(t *testing.T) {
+	tests := map[string]bool{
+		"en":         true,
+		"en-gb":      true,
+		"zh-hant-tw": true,
+		"zh-Hant":    true,
+		"en-uk":      false,
+		"eng":        false,
+		"xx":         false,
+		"en-eu":      false,
+		"en-419":     false,
+		"":           false,
+	}
+	for code, want := range tests {
+		if got := ValidHreflang(code); got != want {
+			t.Errorf("%q: expected %v, got %v", code, want, got)
+		}
+	}
+}
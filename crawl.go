@@ -19,9 +19,14 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/benjaminestes/crawl/analysis"
 	"github.com/benjaminestes/crawl/crawler"
+	"github.com/benjaminestes/crawl/crawler/data"
+	"github.com/benjaminestes/crawl/output"
 	"github.com/benjaminestes/crawl/schema"
 	"github.com/benjaminestes/crawl/sitemap"
 	"github.com/benjaminestes/crawl/version"
@@ -29,11 +34,41 @@ import (
 
 var (
 	spiderCommand = flag.NewFlagSet("spider", flag.ExitOnError)
-	listCommand   = flag.NewFlagSet("list", flag.ExitOnError)
-	listType      = listCommand.String("format",
+	spiderOutput  = spiderCommand.String("output",
+		"", "output format and destination: format[:destination][?options]")
+	listCommand = flag.NewFlagSet("list", flag.ExitOnError)
+	listType    = listCommand.String("format",
 		"text", "format of input for list mode: {text|xml}")
+	listOutput = listCommand.String("output",
+		"", "output format and destination: format[:destination][?options]")
 	sitemapCommand = flag.NewFlagSet("sitemap", flag.ExitOnError)
 	versionCommand = flag.NewFlagSet("version", flag.ExitOnError)
+	dupesCommand   = flag.NewFlagSet("dupes", flag.ExitOnError)
+	dupesThreshold = dupesCommand.Int("threshold",
+		3, "maximum number of differing SimHash bits for near-duplicates: {0-63}")
+	hreflangCommand = flag.NewFlagSet("hreflang", flag.ExitOnError)
+	hreflangFormat  = hreflangCommand.String("format",
+		"json", "format of output: {json|table}")
+	canonicalCommand = flag.NewFlagSet("canonical", flag.ExitOnError)
+	canonicalFormat  = canonicalCommand.String("format",
+		"json", "format of output: {json|table}")
+	schemaCommand = flag.NewFlagSet("schema", flag.ExitOnError)
+	schemaFormat  = schemaCommand.String("format",
+		"bigquery", "format of schema: {bigquery|jsonschema|avro|postgres|duckdb|clickhouse}")
+	schemaTable = schemaCommand.String("table",
+		"crawl", "name of the table, for SQL formats")
+	schemaNormalized = schemaCommand.Bool("normalized",
+		false, "store repeated fields in tables of their own, for postgres")
+	sqlCommand = flag.NewFlagSet("sql", flag.ExitOnError)
+	sqlFormat  = sqlCommand.String("format",
+		"table", "format of output: {json|table}")
+	graphCommand = flag.NewFlagSet("graph", flag.ExitOnError)
+	graphFormat  = graphCommand.String("format",
+		"graphml", "format of output: {graphml|gexf|csv}")
+	graphInternal = graphCommand.Bool("internal",
+		false, "include only links to pages on the same host")
+	graphCollapseRedirects = graphCommand.Bool("collapse-redirects",
+		false, "replace links to redirects with links to their targets")
 )
 
 func main() {
@@ -54,6 +89,16 @@ func main() {
 		doList()
 	case "sitemap":
 		doSitemap()
+	case "dupes":
+		doDupes()
+	case "hreflang":
+		doHreflang()
+	case "canonical":
+		doCanonical()
+	case "graph":
+		doGraph()
+	case "sql":
+		doSQL()
 	case "version":
 		doVersion()
 		os.Exit(0)
@@ -65,8 +110,26 @@ func main() {
 }
 
 func doSchema() {
-	os.Stdout.Write(schema.BigQueryJSON())
-	fmt.Println()
+	schemaCommand.Parse(os.Args[2:])
+	switch *schemaFormat {
+	case "bigquery":
+		os.Stdout.Write(schema.BigQueryJSON())
+		fmt.Println()
+	case "jsonschema":
+		os.Stdout.Write(schema.JSONSchema())
+		fmt.Println()
+	case "avro":
+		os.Stdout.Write(schema.Avro())
+		fmt.Println()
+	case "postgres":
+		fmt.Print(schema.PostgresDDL(*schemaTable, *schemaNormalized))
+	case "duckdb":
+		fmt.Print(schema.DuckDBDDL(*schemaTable))
+	case "clickhouse":
+		fmt.Print(schema.ClickHouseDDL(*schemaTable))
+	default:
+		log.Fatal(fmt.Errorf("unexpected format: %s", *schemaFormat))
+	}
 }
 
 func doVersion() {
@@ -78,15 +141,43 @@ func doSpider() {
 	if spiderCommand.NArg() < 1 {
 		log.Fatal(fmt.Errorf("expected location of config file"))
 	}
-	config, err := os.Open(spiderCommand.Arg(0))
+	c, out := loadConfig(spiderCommand.Arg(0), *spiderOutput)
+	doCrawl(c, out)
+}
+
+// commandConfig holds the keys of a configuration file that are used
+// by the crawl command itself, rather than by the crawler.
+type commandConfig struct {
+	// Output selects the output format and destination, as
+	// "format[:destination]".
+	Output string
+}
+
+// loadConfig reads the configuration file at path, and returns the
+// configured crawler and the sink its results should be written
+// to. If outputSpec is not empty, it overrides the Output key of the
+// configuration.
+func loadConfig(path, outputSpec string) (*crawler.Crawler, output.Sink) {
+	config, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatal(fmt.Errorf("%v", err))
+	}
+	c, err := crawler.FromJSON(bytes.NewReader(config))
 	if err != nil {
 		log.Fatal(fmt.Errorf("%v", err))
 	}
-	c, err := crawler.FromJSON(config)
+	var cc commandConfig
+	if err := json.Unmarshal(config, &cc); err != nil {
+		log.Fatal(fmt.Errorf("%v", err))
+	}
+	if outputSpec == "" {
+		outputSpec = cc.Output
+	}
+	out, err := output.Open(outputSpec)
 	if err != nil {
 		log.Fatal(fmt.Errorf("%v", err))
 	}
-	doCrawl(c)
+	return c, out
 }
 
 func doSitemap() {
@@ -111,11 +202,8 @@ func doList() {
 	if listCommand.NArg() < 1 {
 		log.Fatal(fmt.Errorf("expected location of config file"))
 	}
-	config, err := os.Open(listCommand.Arg(0))
-	if err != nil {
-		log.Fatal(fmt.Errorf("%v", err))
-	}
 	var queue []string
+	var err error
 	switch *listType {
 	case "text":
 		queue = listFromReader(os.Stdin)
@@ -126,16 +214,13 @@ func doList() {
 			log.Fatalf("couldn't parse sitemap from stdin: %v", err)
 		}
 	}
-	c, err := crawler.FromJSON(config)
-	if err != nil {
-		log.Fatal(fmt.Errorf("%v", err))
-	}
+	c, out := loadConfig(listCommand.Arg(0), *listOutput)
 	c.From = queue
 	c.MaxDepth = 0
-	doCrawl(c)
+	doCrawl(c, out)
 }
 
-func doCrawl(c *crawler.Crawler) {
+func doCrawl(c *crawler.Crawler, out output.Sink) {
 	count, lastCount := 0, 0
 	lastUpdate := time.Now()
 	err := c.Start()
@@ -145,20 +230,236 @@ func doCrawl(c *crawler.Crawler) {
 	}
 	log.Printf("crawl started")
 	for n := c.Next(); n != nil; n = c.Next() {
-		j, _ := json.Marshal(n)
-		fmt.Printf("%s\n", j)
+		if err := out.Write(n); err != nil {
+			log.Fatalf("couldn't write result: %v", err)
+		}
 		count++
 		if time.Since(lastUpdate) > 5*time.Second {
 			lastUpdate = time.Now()
 			rate := (count - lastCount) / 5
 			lastCount = count
 			log.Printf("crawled %d (~%d/sec)", count, rate)
+			if err := out.Flush(); err != nil {
+				log.Fatalf("couldn't write results: %v", err)
+			}
 		}
 	}
 
+	if err := out.Close(); err != nil {
+		log.Fatalf("couldn't write results: %v", err)
+	}
+	if err := c.Err(); err != nil {
+		log.Fatalf("crawl stopped after %d URLs: %v", count, err)
+	}
 	log.Printf("crawl complete, %d URLs total", count)
 }
 
+func doDupes() {
+	dupesCommand.Parse(os.Args[2:])
+	if *dupesThreshold < 0 || *dupesThreshold > 63 {
+		log.Fatal(fmt.Errorf("threshold must be between 0 and 63"))
+	}
+
+	f := analysis.NewDuplicateFinder(*dupesThreshold)
+	readCrawlData(dupesCommand, f.Add)
+
+	for _, c := range f.Clusters() {
+		j, _ := json.Marshal(c)
+		fmt.Printf("%s\n", j)
+	}
+}
+
+func doHreflang() {
+	hreflangCommand.Parse(os.Args[2:])
+	rep := newReporter(*hreflangFormat,
+		"CLUSTER", "ISSUE", "ADDRESS", "TARGET", "HREFLANG", "DETAIL")
+
+	v := analysis.NewHreflangValidator()
+	readCrawlData(hreflangCommand, v.Add)
+
+	for _, f := range v.Findings() {
+		rep.report(f, f.Cluster, f.Issue, f.Address, f.Target, f.Hreflang, f.Detail)
+	}
+	rep.flush()
+}
+
+func doCanonical() {
+	canonicalCommand.Parse(os.Args[2:])
+	rep := newReporter(*canonicalFormat,
+		"ADDRESS", "ISSUES", "CANONICAL", "FINAL", "CHAIN")
+
+	a := analysis.NewCanonicalAnalyzer()
+	readCrawlData(canonicalCommand, a.Add)
+
+	for _, f := range a.Findings() {
+		rep.report(f, f.Address, strings.Join(f.Issues, ","), f.Canonical,
+			f.Final, strings.Join(f.Chain, " -> "))
+	}
+	rep.flush()
+}
+
+func doGraph() {
+	graphCommand.Parse(os.Args[2:])
+	var write func(*analysis.Graph, io.Writer) error
+	switch *graphFormat {
+	case "graphml":
+		write = (*analysis.Graph).WriteGraphML
+	case "gexf":
+		write = (*analysis.Graph).WriteGEXF
+	case "csv":
+		write = (*analysis.Graph).WriteEdgeCSV
+	default:
+		log.Fatal(fmt.Errorf("unexpected format: %s", *graphFormat))
+	}
+	b := analysis.NewGraphBuilder(*graphInternal, *graphCollapseRedirects)
+	readCrawlData(graphCommand, b.Add)
+	if err := write(b.Graph(), os.Stdout); err != nil {
+		log.Fatalf("couldn't write graph: %v", err)
+	}
+}
+
+func doSQL() {
+	sqlCommand.Parse(os.Args[2:])
+	if *sqlFormat != "json" && *sqlFormat != "table" {
+		log.Fatal(fmt.Errorf("unexpected format: %s", *sqlFormat))
+	}
+	if sqlCommand.NArg() < 2 {
+		log.Fatal(fmt.Errorf("expected a database and a query (one of %s, or SQL)",
+			strings.Join(output.SQLiteQueries(), ", ")))
+	}
+	query, ok := output.SQLiteQuery(sqlCommand.Arg(1))
+	if !ok {
+		query = sqlCommand.Arg(1)
+	}
+
+	db, err := output.OpenSQLiteDB(sqlCommand.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query(query)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	if *sqlFormat == "table" {
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(cols, "\t")))
+	}
+	vals := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			log.Fatal(err)
+		}
+		for i, v := range vals {
+			// Text may be scanned as bytes, which would be
+			// encoded as base64 in JSON.
+			if b, ok := v.([]byte); ok {
+				vals[i] = string(b)
+			}
+		}
+		if *sqlFormat == "json" {
+			obj := make(map[string]interface{})
+			for i, col := range cols {
+				obj[col] = vals[i]
+			}
+			j, _ := json.Marshal(obj)
+			fmt.Printf("%s\n", j)
+			continue
+		}
+		var fields []string
+		for _, v := range vals {
+			if v == nil {
+				fields = append(fields, "")
+				continue
+			}
+			fields = append(fields, fmt.Sprint(v))
+		}
+		fmt.Fprintln(w, strings.Join(fields, "\t"))
+	}
+	if err := rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+	w.Flush()
+}
+
+// openCrawlData opens the crawl data file named by the first argument
+// of fs, or stdin if there is no argument.
+func openCrawlData(fs *flag.FlagSet) io.ReadCloser {
+	if fs.NArg() < 1 {
+		return os.Stdin
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatal(fmt.Errorf("%v", err))
+	}
+	return f
+}
+
+// readCrawlData reads the crawl data opened by openCrawlData, and
+// calls add with each result.
+func readCrawlData(fs *flag.FlagSet, add func(*data.Result)) {
+	in := openCrawlData(fs)
+	defer in.Close()
+
+	err := analysis.ReadResults(in, func(r *data.Result) error {
+		add(r)
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("couldn't read crawl data: %v", err)
+	}
+}
+
+// A reporter writes the findings of an analysis to stdout, either as
+// newline-delimited JSON or as a table.
+type reporter struct {
+	json  bool
+	table *tabwriter.Writer
+}
+
+// newReporter returns a reporter for format, which must be json or
+// table. Tables have a header row of columns.
+func newReporter(format string, columns ...string) *reporter {
+	switch format {
+	case "json":
+		return &reporter{json: true}
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(columns, "\t"))
+		return &reporter{table: w}
+	}
+	log.Fatal(fmt.Errorf("unexpected format: %s", format))
+	return nil
+}
+
+// report writes a finding: v as JSON, or fields as a row of the
+// table.
+func (r *reporter) report(v interface{}, fields ...string) {
+	if r.json {
+		j, _ := json.Marshal(v)
+		fmt.Printf("%s\n", j)
+		return
+	}
+	fmt.Fprintln(r.table, strings.Join(fields, "\t"))
+}
+
+// flush writes any buffered rows of the table.
+func (r *reporter) flush() {
+	if r.table != nil {
+		r.table.Flush()
+	}
+}
+
 func listFromReader(in io.Reader) []string {
 	var queue []string
 	scanner := bufio.NewScanner(in)
@@ -219,22 +520,75 @@ func doHelp() {
 	fmt.Println("USAGE: crawl <command> [-flags] [args]")
 	fmt.Println()
 	fmt.Println("The following commands are valid:")
-	fmt.Println("\thelp, list, schema, sitemap, spider, version")
+	fmt.Println("\tcanonical, dupes, graph, help, hreflang, list, schema, sitemap,")
+	fmt.Println("\tspider, sql, version")
+	fmt.Println()
+	fmt.Println("canonical\tResolve the canonical of every page in crawl data read")
+	fmt.Println("\t\tfrom a file, or stdin if no file is given, and report")
+	fmt.Println("\t\tcanonicals that redirect, are noindexed, form chains or")
+	fmt.Println("\t\tloops, or conflict with Link headers.")
+	fmt.Println()
+	fmt.Println("\t\tThe -format={json|table} flag determines the output type.")
+	fmt.Println()
+	fmt.Println("\t\tExample:")
+	fmt.Println("\t\tcrawl canonical -format=table out.txt")
+	fmt.Println()
+	fmt.Println("dupes\t\tFind clusters of near-duplicate pages in crawl data read")
+	fmt.Println("\t\tfrom a file, or stdin if no file is given.")
+	fmt.Println()
+	fmt.Println("\t\tThe -threshold flag sets how many bits of two pages'")
+	fmt.Println("\t\tSimHashes may differ (default 3).")
+	fmt.Println()
+	fmt.Println("\t\tExample:")
+	fmt.Println("\t\tcrawl dupes -threshold=5 out.txt >dupes.txt")
+	fmt.Println()
+	fmt.Println("graph\t\tWrite the link graph of crawl data read from a file, or")
+	fmt.Println("\t\tstdin if no file is given, with pages as nodes, and links")
+	fmt.Println("\t\tand redirects as edges.")
+	fmt.Println()
+	fmt.Println("\t\tThe -format={graphml|gexf|csv} flag determines the output")
+	fmt.Println("\t\ttype; csv writes only the edges. The -internal flag leaves")
+	fmt.Println("\t\tout links to other hosts, and -collapse-redirects replaces")
+	fmt.Println("\t\tlinks to redirects with links to their targets, leaving")
+	fmt.Println("\t\tout the redirects themselves.")
+	fmt.Println()
+	fmt.Println("\t\tExample:")
+	fmt.Println("\t\tcrawl graph -format=gexf -internal out.txt >site.gexf")
 	fmt.Println()
 	fmt.Println("help\t\tPrint this message.")
 	fmt.Println()
+	fmt.Println("hreflang\tCheck the hreflang annotations in crawl data read from")
+	fmt.Println("\t\ta file, or stdin if no file is given, and report problems")
+	fmt.Println("\t\tsuch as missing return links and invalid codes.")
+	fmt.Println()
+	fmt.Println("\t\tThe -format={json|table} flag determines the output type.")
+	fmt.Println()
+	fmt.Println("\t\tExample:")
+	fmt.Println("\t\tcrawl hreflang -format=table out.txt")
+	fmt.Println()
 	fmt.Println("list\t\tCrawl a list of URLs provided on stdin.")
 	fmt.Println()
 	fmt.Println("\t\tThe -format={text|xml} flag determines the expected type.")
+	fmt.Println("\t\tThe -output flag selects the output format and destination,")
+	fmt.Println("\t\tas for spider.")
 	fmt.Println()
 	fmt.Println("\t\tExample:")
 	fmt.Println("\t\tcrawl list config.json <url_list.txt >out.txt")
 	fmt.Println("\t\tcrawl list -format=xml config.json <sitemap.xml >out.txt")
 	fmt.Println()
-	fmt.Println("schema\t\tPrint a BigQuery-compatible JSON schema to stdout.")
+	fmt.Println("schema\t\tPrint a schema for crawl data to stdout.")
+	fmt.Println()
+	fmt.Println("\t\tThe -format flag selects the kind of schema: a BigQuery")
+	fmt.Println("\t\tJSON schema (bigquery, the default), a JSON Schema")
+	fmt.Println("\t\t(jsonschema), an Avro schema (avro), or statements creating")
+	fmt.Println("\t\ttables in PostgreSQL, DuckDB, or ClickHouse (postgres,")
+	fmt.Println("\t\tduckdb, clickhouse). For SQL formats, the -table flag names")
+	fmt.Println("\t\tthe table (default crawl). For postgres, -normalized creates")
+	fmt.Println("\t\tthe tables of the normalized mode of the postgres output.")
 	fmt.Println()
 	fmt.Println("\t\tExample:")
 	fmt.Println("\t\tcrawl schema >schema.json")
+	fmt.Println("\t\tcrawl schema -format=duckdb -table=pages >pages.sql")
 	fmt.Println()
 	fmt.Println("sitemap\t\tRecursively requests a sitemap or sitemap index from")
 	fmt.Println("\t\ta URL provided as argument.")
@@ -244,8 +598,29 @@ func doHelp() {
 	fmt.Println()
 	fmt.Println("spider\t\tCrawl from the URLs specific in the configuration file.")
 	fmt.Println()
+	fmt.Println("\t\tThe -output=format[:destination][?options] flag selects the output")
+	fmt.Println("\t\tformat and destination, overriding the Output key of the")
+	fmt.Println("\t\tconfiguration file. The default is ndjson to stdout.")
+	fmt.Println("\t\tThe csv and tsv formats write one table per repeated")
+	fmt.Println("\t\tfield, and require a file name prefix as destination.")
+	fmt.Println()
 	fmt.Println("\t\tExample:")
 	fmt.Println("\t\tcrawl spider config.json >out.txt")
+	fmt.Println("\t\tcrawl spider -output=ndjson:out.txt config.json")
+	fmt.Println("\t\tcrawl spider '-output=ndjson:crawl.jsonl?compress=gzip&size=1G' config.json")
+	fmt.Println("\t\tcrawl spider -output=csv:crawl config.json")
+	fmt.Println("\t\tcrawl spider '-output=parquet:crawl.parquet?compression=zstd' config.json")
+	fmt.Println()
+	fmt.Println("sql\t\tRun a query against a database written by the sqlite")
+	fmt.Println("\t\toutput format. The query is either SQL or the name of a")
+	fmt.Println("\t\tbuilt-in query: " + strings.Join(output.SQLiteQueries(), ", ") + ".")
+	fmt.Println()
+	fmt.Println("\t\tThe -format={json|table} flag determines the output type.")
+	fmt.Println()
+	fmt.Println("\t\tExample:")
+	fmt.Println("\t\tcrawl spider -output=sqlite:crawl.db config.json")
+	fmt.Println("\t\tcrawl sql crawl.db inlinks")
+	fmt.Println("\t\tcrawl sql crawl.db 'SELECT address_full FROM pages WHERE status_code = 404'")
 	fmt.Println()
 	fmt.Println("version\t\tPrint the version.")
 }
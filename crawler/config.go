@@ -8,30 +8,35 @@ import (
 	"github.com/benjaminestes/crawl/version"
 )
 
-var defaultCrawler = Crawler{
-	Connections:     1,
-	MaxDepth:        0,
-	UserAgent:       version.UserAgent(),
-	RobotsUserAgent: "Crawler",
-
-	// These fields must be set to avoid time parsing errors,
-	// and to keep non-zero defaults colocated in this file.
-	WaitTime:        "100ms",
-	Timeout:         "30s",
+// defaultCrawler returns a new Crawler with default
+// configuration. It is a function rather than a value because a
+// Crawler contains a sync.Mutex, which must not be copied.
+func defaultCrawler() *Crawler {
+	return &Crawler{
+		Connections:     1,
+		MaxDepth:        0,
+		UserAgent:       version.UserAgent(),
+		RobotsUserAgent: "Crawler",
+
+		// These fields must be set to avoid time parsing errors,
+		// and to keep non-zero defaults colocated in this file.
+		WaitTime: "100ms",
+		Timeout:  "30s",
+	}
 }
 
 func FromJSON(in io.Reader) (*Crawler, error) {
-	config := defaultCrawler
+	config := defaultCrawler()
 
 	configJSON, err := ioutil.ReadAll(in)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(configJSON, &config)
+	err = json.Unmarshal(configJSON, config)
 	if err != nil {
 		return nil, err
 	}
 
-	return &config, nil
+	return config, nil
 }
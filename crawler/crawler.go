@@ -7,6 +7,10 @@
 package crawler
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -14,6 +18,8 @@ import (
 	"time"
 
 	"github.com/benjaminestes/crawl/crawler/data"
+	"github.com/benjaminestes/crawl/version"
+	"github.com/benjaminestes/crawl/warc"
 	"github.com/benjaminestes/robots/v2"
 )
 
@@ -42,23 +48,32 @@ func (c *Crawler) initialQueue() ([]resolvedURL, error) {
 
 type Crawler struct {
 	// Exported configuration fields.
-	Connections     int
-	UserAgent       string
-	RobotsUserAgent string
-	Include         []string
-	Exclude         []string
-	From            []string
-	RespectNofollow bool
-	MaxDepth        int
-	WaitTime        string
-	Timeout         string
-	Header          []*data.Pair
+	Connections       int
+	UserAgent         string
+	RobotsUserAgent   string
+	Include           []string
+	Exclude           []string
+	From              []string
+	RespectNofollow   bool
+	FetchResources    bool
+	FollowMetaRefresh bool
+	FollowHeadLinks   bool
+	WARC              string
+	MaxDepth          int
+	WaitTime          string
+	Timeout           string
+	Header            []*data.Pair
 
 	depth   int
 	queue   []resolvedURL
 	seen    map[resolvedURL]bool
 	results chan *data.Result
 
+	// resources maps queued URLs that were discovered as resources
+	// of a page rather than as links to the depth of that page. It
+	// is guarded by mu.
+	resources map[resolvedURL]int
+
 	// robots maintains a robots.txt matcher for every encountered
 	// domain
 	robots map[string]func(string) bool
@@ -87,6 +102,14 @@ type Crawler struct {
 	exclude []*regexp.Regexp
 
 	client *http.Client
+
+	// warc archives requests and responses if Config.WARC names
+	// a file.
+	warc *warc.Writer
+
+	// err is the first error that stopped the crawl, such as a
+	// failure to write to the WARC file. It is guarded by mu.
+	err error
 }
 
 // initializeClient uses a config object to create an http.Client
@@ -102,6 +125,9 @@ func initializedClient(c *Crawler) *http.Client {
 		Transport: &http.Transport{
 			MaxIdleConns:    c.Connections,
 			IdleConnTimeout: c.idleConnTimeout,
+			// Responses are archived as they were received,
+			// so they must not be transparently decompressed.
+			DisableCompression: c.WARC != "",
 		},
 	}
 }
@@ -114,7 +140,7 @@ func initializedClient(c *Crawler) *http.Client {
 // If Start returns a non-nil error, calls to Next will fail.
 func (c *Crawler) Start() error {
 	var err error
-	
+
 	if c.wait, err = time.ParseDuration(c.WaitTime); err != nil {
 		return err
 	}
@@ -127,12 +153,19 @@ func (c *Crawler) Start() error {
 		return err
 	}
 
+	if c.WARC != "" {
+		if c.warc, err = warc.Create(c.WARC, version.UserAgent()); err != nil {
+			return err
+		}
+	}
+
 	c.client = initializedClient(c)
 	c.connections = make(chan bool, c.Connections)
 	c.exclude = preparePattern(c.Exclude)
 	c.include = preparePattern(c.Include)
 	c.robots = make(map[string]func(string) bool)
 	c.seen = make(map[resolvedURL]bool)
+	c.resources = make(map[resolvedURL]int)
 
 	// If a URL has not been seen when the crawler processes a
 	// link, that URL will be added to the next queue to crawl. It
@@ -148,6 +181,11 @@ func (c *Crawler) Start() error {
 	go func() {
 		for f := crawlStartQueue; f != nil; f = f(c) {
 		}
+		if c.warc != nil {
+			if err := c.warc.Close(); err != nil {
+				c.fail(err)
+			}
+		}
 		close(c.results)
 	}()
 
@@ -208,6 +246,24 @@ func (c *Crawler) Next() *data.Result {
 	return node
 }
 
+// Err returns the error that stopped the crawl, if any. Once Next has
+// returned nil, it also reports an error closing the WARC file.
+func (c *Crawler) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// fail stops the crawl because of err, unless it has already been
+// stopped by another error.
+func (c *Crawler) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+}
+
 // resetWait sets the last time the crawler spawned a request.
 func (c *Crawler) resetWait() {
 	c.lastRequestTime = time.Now()
@@ -235,8 +291,9 @@ func (c *Crawler) merge(links []*data.Link) {
 			continue
 		}
 
-		// This is the only place that c.seen is inspected or
-		// mutated after it is initialized.
+		// This and mergeResources are the only places that
+		// c.seen is inspected or mutated after it is
+		// initialized.
 		c.mu.Lock()
 		if _, ok := c.seen[linkURL]; !ok {
 			if !(link.Nofollow && c.RespectNofollow) {
@@ -248,18 +305,119 @@ func (c *Crawler) merge(links []*data.Link) {
 	}
 }
 
+// hyperlinks returns the links that a user could follow to another
+// page. Only these are crawled; other links, such as form actions,
+// are recorded but not followed.
+func hyperlinks(links []*data.Link) (filtered []*data.Link) {
+	for _, link := range links {
+		if link.IsHyperlink() {
+			filtered = append(filtered, link)
+		}
+	}
+	return
+}
+
+// metaRefreshLinks returns links to the targets of the meta refresh
+// tags of result, so that they can be merged like the target of a
+// server-side redirect.
+func metaRefreshLinks(result *data.Result) (links []*data.Link) {
+	for _, r := range result.ClientRedirects {
+		if r.Type == data.RedirectMetaRefresh && r.Address != nil {
+			links = append(links, &data.Link{
+				Address: r.Address,
+			})
+		}
+	}
+	return
+}
+
+// headLinks returns links to the pages referred to by the head links
+// of result, such as the next page in a paginated series.
+func headLinks(result *data.Result) (links []*data.Link) {
+	for _, h := range result.HeadLinks {
+		if h.IsPage() && h.Address != nil {
+			links = append(links, &data.Link{
+				Address: h.Address,
+			})
+		}
+	}
+	return
+}
+
+// mergeResources takes the addresses of resources used by a page and
+// adds them to the next queue to be crawled, if c.FetchResources is
+// set. Resources don't count toward the depth of the crawl, so they
+// are merged even if the next level of pages won't be crawled, and
+// are reported at depth, the depth of the page that used them.
+func (c *Crawler) mergeResources(addrs []*data.Address, depth int) {
+	if !c.FetchResources {
+		return
+	}
+	for _, addr := range addrs {
+		if addr == nil {
+			continue
+		}
+
+		resourceURL := resolvedURL(addr.Full)
+
+		if !c.willCrawl(resourceURL) {
+			continue
+		}
+
+		c.mu.Lock()
+		if _, ok := c.seen[resourceURL]; !ok {
+			c.seen[resourceURL] = true
+			c.resources[resourceURL] = depth
+			c.nextqueue = append(c.nextqueue, resourceURL)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// resourceTypes are the types of data.Resource that are fetched when
+// c.FetchResources is set, in addition to images.
+var resourceTypes = map[string]bool{
+	"script":     true,
+	"stylesheet": true,
+	"font":       true,
+}
+
+// resourceAddresses returns the addresses of the images and
+// resources of result that should be fetched as resources.
+func resourceAddresses(result *data.Result) (addrs []*data.Address) {
+	for _, image := range result.Images {
+		addrs = append(addrs, image.Address)
+	}
+	for _, resource := range result.Resources {
+		if resourceTypes[resource.Type] {
+			addrs = append(addrs, resource.Address)
+		}
+	}
+	return
+}
+
 // fetch requests a URL, hydrates a result object based on its
 // contents, if any, and initiates a merge of the links discovered in
 // the process.
 func (c *Crawler) fetch(addr resolvedURL) {
+	if depth, ok := c.resourceDepth(addr); ok {
+		c.fetchResource(addr, depth)
+		return
+	}
+
+	start := time.Now()
 	resp, err := requestAsCrawler(c, addr)
 	if err != nil {
 		// FIXME: Should this panic? Under what conditions would this fail?
 		return
 	}
 	defer resp.Body.Close()
-	
+	elapsed := time.Since(start)
+	body, truncated := c.bufferBody(resp)
+
 	result := data.MakeResult(addr.String(), c.depth, resp)
+	result.ResponseTimeMs = elapsed.Milliseconds()
+	c.archive(result, resp, body, truncated, start)
 
 	if resp != nil && resp.StatusCode >= 300 && resp.StatusCode < 400 {
 		c.merge([]*data.Link{
@@ -269,10 +427,113 @@ func (c *Crawler) fetch(addr resolvedURL) {
 		})
 	}
 
-	c.merge(result.Links)
+	if c.FollowMetaRefresh {
+		c.merge(metaRefreshLinks(result))
+	}
+
+	if c.FollowHeadLinks {
+		c.merge(headLinks(result))
+	}
+
+	c.merge(hyperlinks(result.Links))
+	c.mergeResources(resourceAddresses(result), result.Depth)
+	c.results <- result
+}
+
+// fetchResource requests a resource with HEAD, falling back to GET if
+// the server doesn't support HEAD. If the resource redirects, its
+// target is merged as a resource as well. The result is reported at
+// depth, the depth of the page that used the resource.
+func (c *Crawler) fetchResource(addr resolvedURL, depth int) {
+	start := time.Now()
+	resp, err := requestMethodAsCrawler(c, "HEAD", addr)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed ||
+		resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		start = time.Now()
+		resp, err = requestAsCrawler(c, addr)
+	}
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	elapsed := time.Since(start)
+	body, truncated := c.bufferBody(resp)
+
+	result := data.MakeResourceResult(addr.String(), depth, resp)
+	result.ResponseTimeMs = elapsed.Milliseconds()
+	c.archive(result, resp, body, truncated, start)
+
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		c.mergeResources([]*data.Address{result.ResolvesTo}, depth)
+	}
+
 	c.results <- result
 }
 
+// resourceDepth reports whether addr was queued as a resource, and
+// if so, the depth of the page that used it.
+func (c *Crawler) resourceDepth(addr resolvedURL) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	depth, ok := c.resources[addr]
+	return depth, ok
+}
+
+// maxArchivedBody is the number of bytes of a response body beyond
+// which it is truncated in the WARC file. It is a variable so that
+// tests can lower it.
+var maxArchivedBody int64 = 16 << 20
+
+// bufferBody reads the body of resp, if the crawl is being archived,
+// and replaces it with a reader of the same bytes, so that it can be
+// both hydrated and archived. Only the first maxArchivedBody bytes
+// are kept, and truncated reports whether the body is longer; the
+// rest is read from the response as it is hydrated. If the crawl
+// isn't being archived, it returns nil.
+func (c *Crawler) bufferBody(resp *http.Response) (body []byte, truncated bool) {
+	if c.warc == nil {
+		return nil, false
+	}
+	// A body that can't be read completely is archived as far as
+	// it was read.
+	buf, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxArchivedBody+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), resp.Body), resp.Body}
+	if int64(len(buf)) > maxArchivedBody {
+		return buf[:maxArchivedBody], true
+	}
+	return buf, false
+}
+
+// archive writes the request and response for result to the WARC
+// file, if any, along with metadata describing the fetch, and records
+// where the response was written in result. If the WARC file can't be
+// written, the crawl is stopped.
+func (c *Crawler) archive(result *data.Result, resp *http.Response, body []byte, truncated bool, date time.Time) {
+	if c.warc == nil {
+		return
+	}
+	metadata := []warc.Field{
+		{Name: "fetchTimeMs", Value: fmt.Sprint(result.ResponseTimeMs)},
+		{Name: "depth", Value: fmt.Sprint(result.Depth)},
+	}
+	for _, l := range hyperlinks(result.Links) {
+		if l.Address != nil {
+			metadata = append(metadata, warc.Field{Name: "outlink", Value: l.Address.Full})
+		}
+	}
+	offset, err := c.warc.WriteExchange(resp, body, truncated, date, metadata)
+	if err != nil {
+		c.fail(fmt.Errorf("couldn't archive %s: %v", result.Address.Full, err))
+		return
+	}
+	result.WARCFilename = c.warc.Name()
+	result.WARCOffset = offset
+}
+
 // addRobots creates a robots.txt matcher from a URL string. If there
 // is a problem reading from robots.txt, treat it as a server error.
 func (c *Crawler) addRobots(u resolvedURL) {
@@ -294,17 +555,24 @@ func (c *Crawler) addRobots(u resolvedURL) {
 	c.robots[u.String()] = rtxt.Tester(c.RobotsUserAgent)
 }
 
-// 
+// requestAsCrawler makes a GET request for u with the user agent and
+// headers configured for c.
 func requestAsCrawler(c *Crawler, u resolvedURL) (*http.Response, error) {
-	req, err := http.NewRequest("GET", u.String(), nil)
+	return requestMethodAsCrawler(c, "GET", u)
+}
+
+// requestMethodAsCrawler is like requestAsCrawler, but allows the
+// request method to be specified.
+func requestMethodAsCrawler(c *Crawler, method string, u resolvedURL) (*http.Response, error) {
+	req, err := http.NewRequest(method, u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	req.Header.Set("User-Agent", c.UserAgent)
 	for _, h := range c.Header {
 		req.Header.Add(h.K, h.V)
 	}
-	
+
 	return c.client.Do(req)
 }
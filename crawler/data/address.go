@@ -6,12 +6,12 @@ import "net/url"
 // available for analysis. It is the basic type which other
 // address-related types embed.
 type Address struct {
-	Full   string
-	Scheme string
-	Opaque string
-	Host   string
-	Path   string
-	Query  string
+	Full   string `description:"The full URL."`
+	Scheme string `description:"The scheme of the URL, like https."`
+	Opaque string `description:"The opaque part of the URL, if any."`
+	Host   string `description:"The host of the URL, including any port."`
+	Path   string `description:"The path of the URL."`
+	Query  string `description:"The query string of the URL, without the leading question mark."`
 }
 
 func MakeAddress(rawurl string) *Address {
@@ -1,8 +1,8 @@
 package data
 
 type Canonical struct {
-	Address *Address
-	Href    string
+	Address *Address `description:"The address the canonical refers to, resolved against the page."`
+	Href    string   `description:"The canonical URL as written."`
 }
 
 func MakeCanonical(base *Address, href string) *Canonical {
@@ -0,0 +1,47 @@
+package data
+
+// Heading is a single h1–h6 element. Position is the index of the
+// heading among all headings on the page, starting at 0, so that the
+// outline of the page can be reconstructed.
+type Heading struct {
+	Level    int    `description:"The level of the heading, from 1 to 6."`
+	Text     string `description:"The text of the heading."`
+	Position int    `description:"The index of the heading among all headings on the page, starting at 0."`
+}
+
+func MakeHeading(level int, text string, position int) *Heading {
+	heading := &Heading{
+		Level:    level,
+		Text:     text,
+		Position: position,
+	}
+	return heading
+}
+
+// HeadingCounts records how many headings of each level appear on a
+// page.
+type HeadingCounts struct {
+	H1 int `description:"The number of h1 elements."`
+	H2 int `description:"The number of h2 elements."`
+	H3 int `description:"The number of h3 elements."`
+	H4 int `description:"The number of h4 elements."`
+	H5 int `description:"The number of h5 elements."`
+	H6 int `description:"The number of h6 elements."`
+}
+
+func (hc *HeadingCounts) add(level int) {
+	switch level {
+	case 1:
+		hc.H1++
+	case 2:
+		hc.H2++
+	case 3:
+		hc.H3++
+	case 4:
+		hc.H4++
+	case 5:
+		hc.H5++
+	case 6:
+		hc.H6++
+	}
+}
@@ -0,0 +1,30 @@
+package data
+
+// HeadLink is a <link> element describing a page related to the
+// current one, other than by hreflang or canonical: the next or
+// previous page of a paginated series (Rel "next" or "prev"), an AMP
+// version of the page ("amphtml"), or another alternate version, such
+// as a mobile page identified by Media or a feed identified by Type
+// ("alternate").
+type HeadLink struct {
+	Address *Address `description:"The address of the related page, resolved against the page."`
+	Href    string   `description:"The href attribute as written."`
+	Rel     string   `description:"The rel attribute: next, prev, amphtml, or alternate."`
+	Media   string   `description:"The media attribute, identifying an alternate version like a mobile page."`
+	Type    string   `description:"The type attribute, identifying an alternate version like a feed."`
+}
+
+func MakeHeadLink(base *Address, href, rel string) *HeadLink {
+	headLink := &HeadLink{
+		Href:    href,
+		Rel:     rel,
+		Address: MakeAddressResolved(base, href),
+	}
+	return headLink
+}
+
+// IsPage reports whether the head link refers to an HTML page, as
+// opposed to, e.g., an RSS feed.
+func (h *HeadLink) IsPage() bool {
+	return h.Type == "" || h.Type == "text/html"
+}
@@ -0,0 +1,45 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestGetHeadLinks(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<head>
+<link rel="previous" href="/page/1">
+<link rel="next" href="/page/3">
+<link rel="amphtml" href="/amp/page/2">
+<link rel="alternate" media="only screen and (max-width: 640px)" href="https://m.example.com/page/2">
+<link rel="alternate" type="application/rss+xml" href="/feed">
+<link rel="alternate" hreflang="de" href="/de/page/2">
+<link rel="stylesheet" href="/main.css">
+</head>`))
+	if err != nil {
+		t.Fatalf("couldn't parse test data")
+	}
+
+	headLinks := getHeadLinks(MakeAddress("https://example.com/page/2"), doc)
+	want := []string{"prev", "next", "amphtml", "alternate", "alternate"}
+	if len(headLinks) != len(want) {
+		t.Fatalf("expected %d head links, got %d", len(want), len(headLinks))
+	}
+	for i, h := range headLinks {
+		if h.Rel != want[i] {
+			t.Errorf("expected rel %s, got %s", want[i], h.Rel)
+		}
+	}
+	if headLinks[3].Media == "" || !headLinks[3].IsPage() {
+		t.Errorf("expected mobile alternate page, got %+v", headLinks[3])
+	}
+	if headLinks[4].IsPage() {
+		t.Errorf("feed shouldn't be a page: %+v", headLinks[4])
+	}
+
+	hreflang := getHreflang(MakeAddress("https://example.com/page/2"), doc)
+	if len(hreflang) != 1 || hreflang[0].Hreflang != "de" {
+		t.Errorf("expected only the hreflang alternate, got %d", len(hreflang))
+	}
+}
@@ -1,9 +1,9 @@
 package data
 
 type Hreflang struct {
-	Address  *Address
-	Href     string
-	Hreflang string
+	Address  *Address `description:"The address of the alternate page, resolved against the page."`
+	Href     string   `description:"The URL of the alternate page as written."`
+	Hreflang string   `description:"The language and optional region code of the alternate page, like en-GB, or x-default."`
 }
 
 func MakeHreflang(base *Address, href, lang string) *Hreflang {
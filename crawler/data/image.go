@@ -0,0 +1,92 @@
+package data
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Image describes a single image candidate found on a page. An <img>
+// or <source> element may produce several Images: one for its src
+// attribute, and one for each candidate in its srcset attribute.
+//
+// Alt is taken from the <img> element, even for a <source> inside a
+// <picture>. HasAlt distinguishes a missing alt attribute from an
+// empty one.
+type Image struct {
+	Address    *Address `description:"The address of the image, resolved against the page."`
+	Href       string   `description:"The URL of the image as written."`
+	Element    string   `description:"The element the image came from: img or source."`
+	Attribute  string   `description:"The attribute the image came from: src or srcset."`
+	Descriptor string   `description:"The width or density descriptor of a srcset candidate, like 2x."`
+	Alt        string   `description:"The alt text of the image."`
+	HasAlt     bool     `description:"Whether the img element has an alt attribute, even an empty one."`
+	Width      string   `description:"The width attribute as written."`
+	Height     string   `description:"The height attribute as written."`
+	Loading    string   `description:"The loading attribute, like lazy."`
+}
+
+func MakeImage(base *Address, href, element, attribute, descriptor string) *Image {
+	image := &Image{
+		Href:       href,
+		Element:    element,
+		Attribute:  attribute,
+		Descriptor: descriptor,
+		Address:    MakeAddressResolved(base, href),
+	}
+	return image
+}
+
+// srcsetCandidate is a single URL and its (possibly empty)
+// descriptor from a srcset attribute.
+type srcsetCandidate struct {
+	href       string
+	descriptor string
+}
+
+// parseSrcset splits a srcset attribute into its candidates. It
+// follows the outline of the algorithm in the HTML specification:
+// URLs may contain commas, so a candidate only ends at a comma that
+// follows whitespace or a descriptor.
+func parseSrcset(s string) (candidates []srcsetCandidate) {
+	for {
+		s = strings.TrimLeftFunc(s, func(r rune) bool {
+			return unicode.IsSpace(r) || r == ','
+		})
+		if s == "" {
+			return
+		}
+
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			end = len(s)
+		}
+		href := s[:end]
+		s = s[end:]
+
+		// A URL ending in a comma has no descriptor.
+		if strings.HasSuffix(href, ",") {
+			href = strings.TrimRight(href, ",")
+			candidates = append(candidates, srcsetCandidate{href, ""})
+			continue
+		}
+
+		// Otherwise, the descriptor runs until the next comma
+		// that is not inside parentheses.
+		depth, end := 0, len(s)
+	loop:
+		for i, r := range s {
+			switch {
+			case r == '(':
+				depth++
+			case r == ')' && depth > 0:
+				depth--
+			case r == ',' && depth == 0:
+				end = i
+				break loop
+			}
+		}
+		descriptor := strings.Join(strings.Fields(s[:end]), " ")
+		s = s[end:]
+		candidates = append(candidates, srcsetCandidate{href, descriptor})
+	}
+}
@@ -0,0 +1,65 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		srcset string
+		want   []srcsetCandidate
+	}{
+		{"", nil},
+		{"a.jpg", []srcsetCandidate{{"a.jpg", ""}}},
+		{"a.jpg 1x, b.jpg 2x", []srcsetCandidate{{"a.jpg", "1x"}, {"b.jpg", "2x"}}},
+		{" a.jpg  640w,b.jpg 1280w ", []srcsetCandidate{{"a.jpg", "640w"}, {"b.jpg", "1280w"}}},
+		{"a,b.jpg 1x, c.jpg,", []srcsetCandidate{{"a,b.jpg", "1x"}, {"c.jpg", ""}}},
+	}
+	for _, test := range tests {
+		got := parseSrcset(test.srcset)
+		if len(got) != len(test.want) {
+			t.Errorf("%q: expected %d candidates, got %d", test.srcset, len(test.want), len(got))
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%q: expected %v, got %v", test.srcset, test.want[i], got[i])
+			}
+		}
+	}
+}
+
+func TestGetImages(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<body>
+<img src="/a.jpg" alt="" width="10" loading="lazy">
+<picture><source srcset="b.webp 1x, c.webp 2x"><img src="d.jpg"></picture>
+<video><source src="e.mp4"></video>`))
+	if err != nil {
+		t.Fatalf("couldn't parse test data")
+	}
+
+	images := getImages(MakeAddress("https://example.com/x/"), doc)
+	want := []string{
+		"https://example.com/a.jpg",
+		"https://example.com/x/b.webp",
+		"https://example.com/x/c.webp",
+		"https://example.com/x/d.jpg",
+	}
+	if len(images) != len(want) {
+		t.Fatalf("expected %d images, got %d", len(want), len(images))
+	}
+	for i, image := range images {
+		if image.Address.Full != want[i] {
+			t.Errorf("expected %s, got %s", want[i], image.Address.Full)
+		}
+	}
+	if !images[0].HasAlt || images[0].Loading != "lazy" || images[0].Width != "10" {
+		t.Errorf("unexpected attributes for first image: %+v", images[0])
+	}
+	if images[1].HasAlt || images[2].Descriptor != "2x" {
+		t.Errorf("unexpected attributes for picture source: %+v", images[1])
+	}
+}
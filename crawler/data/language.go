@@ -0,0 +1,44 @@
+package data
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/abadojack/whatlanggo"
+)
+
+// maxLanguageSample is the number of bytes of text used to detect
+// the language of a page. More text adds little accuracy.
+const maxLanguageSample = 10000
+
+// minLanguageWords is the number of words below which the language
+// of text is not detected, since the result would be little better
+// than a guess.
+const minLanguageWords = 10
+
+// detectLanguage returns the ISO 639-1 code of the language of text
+// (or the ISO 639-3 code, for languages that have no 639-1 code), and
+// the confidence of the detection between 0 and 1. It returns the
+// empty string if the language can't be detected.
+func detectLanguage(text string) (string, float64) {
+	if len(strings.Fields(text)) < minLanguageWords {
+		return "", 0
+	}
+	if len(text) > maxLanguageSample {
+		// Don't cut the text in the middle of a character.
+		end := maxLanguageSample
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		text = text[:end]
+	}
+	info := whatlanggo.Detect(text)
+	if info.Lang < 0 || info.Confidence == 0 {
+		return "", 0
+	}
+	code := info.Lang.Iso6391()
+	if code == "" {
+		code = info.Lang.Iso6393()
+	}
+	return code, info.Confidence
+}
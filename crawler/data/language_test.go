@@ -0,0 +1,47 @@
+package data
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	body := `<html lang="de-DE"><body><main>
+<p>Die Würde des Menschen ist unantastbar. Sie zu achten und zu schützen
+ist Verpflichtung aller staatlichen Gewalt. Das Deutsche Volk bekennt sich
+darum zu unverletzlichen und unveräußerlichen Menschenrechten als
+Grundlage jeder menschlichen Gemeinschaft, des Friedens und der
+Gerechtigkeit in der Welt.</p>
+</main></body></html>`
+	resp := &http.Response{
+		StatusCode: 200,
+		Header: http.Header{
+			"Content-Type":     {"text/html"},
+			"Content-Language": {"de"},
+		},
+		Body: ioutil.NopCloser(strings.NewReader(body)),
+	}
+
+	r := MakeResult("https://example.com/de/", 0, resp)
+	if r.HTMLLang != "de-DE" || r.ContentLanguage != "de" {
+		t.Errorf("unexpected declared languages: %q, %q", r.HTMLLang, r.ContentLanguage)
+	}
+	if r.DetectedLanguage != "de" || r.DetectedLanguageConfidence <= 0 {
+		t.Errorf("expected to detect de, got %q (%f)", r.DetectedLanguage, r.DetectedLanguageConfidence)
+	}
+}
+
+func TestDetectLanguageLongText(t *testing.T) {
+	text := strings.Repeat("Die Würde des Menschen ist unantastbar. ", maxLanguageSample/10)
+	if lang, _ := detectLanguage(text); lang != "de" {
+		t.Errorf("expected to detect de in long text, got %q", lang)
+	}
+}
+
+func TestDetectLanguageShortText(t *testing.T) {
+	if lang, _ := detectLanguage("Hi there"); lang != "" {
+		t.Errorf("expected no language for short text, got %q", lang)
+	}
+}
@@ -1,18 +1,68 @@
 package data
 
+import "strings"
+
+// Link describes an element on a page that refers to another URL:
+// an <a> or <area> hyperlink, a navigational <link> in the head, the
+// action of a <form>, or an <iframe>. Element is the name of the
+// element the link came from.
+//
+// Rel holds the tokens of the rel attribute, lowercased. Nofollow,
+// UGC, and Sponsored report whether the corresponding token is
+// present. InNav, InHeader, InFooter, and InMain report whether the
+// link appears within an element of that name.
+//
+// Position is the index of the link among all links on the page,
+// starting at 0. Landmark is the nearest enclosing landmark region
+// (nav, header, footer, aside, main, or article), identified by
+// element name or ARIA role. DOMPath is a CSS-like path to the link
+// element. ImageLink is true if the link contains an image; if it
+// has no text, the alt text of the image is used as its Anchor.
 type Link struct {
-	Address  *Address
-	Anchor   string
-	Href     string
-	Nofollow bool
+	Address   *Address `description:"The address of the link target, resolved against the page."`
+	Anchor    string   `description:"The text of the link, or the alt text of its image if it has no text."`
+	Href      string   `description:"The URL of the link as written."`
+	Element   string   `description:"The element the link came from, like a, area, link, form, or iframe."`
+	Rel       []string `description:"The tokens of the rel attribute, lowercased."`
+	Nofollow  bool     `description:"Whether the rel attribute includes nofollow."`
+	UGC       bool     `description:"Whether the rel attribute includes ugc."`
+	Sponsored bool     `description:"Whether the rel attribute includes sponsored."`
+	Target    string   `description:"The target attribute, like _blank."`
+	Hreflang  string   `description:"The hreflang attribute."`
+	Type      string   `description:"The type attribute."`
+	InNav     bool     `description:"Whether the link is within a nav element."`
+	InHeader  bool     `description:"Whether the link is within a header element."`
+	InFooter  bool     `description:"Whether the link is within a footer element."`
+	InMain    bool     `description:"Whether the link is within a main element."`
+	Position  int      `description:"The index of the link among all links on the page, starting at 0."`
+	Landmark  string   `description:"The nearest enclosing landmark region: nav, header, footer, aside, main, or article."`
+	DOMPath   string   `description:"A CSS-like path to the link element."`
+	ImageLink bool     `description:"Whether the link contains an image."`
 }
 
-func MakeLink(base *Address, href string, anchor string, nofollow bool) *Link {
+func MakeLink(base *Address, href string, anchor string, rel string) *Link {
 	link := &Link{
-		Href:     href,
-		Anchor:   anchor,
-		Nofollow: nofollow,
-		Address:  MakeAddressResolved(base, href),
+		Href:    href,
+		Anchor:  anchor,
+		Rel:     strings.Fields(strings.ToLower(rel)),
+		Address: MakeAddressResolved(base, href),
+	}
+	for _, tok := range link.Rel {
+		switch tok {
+		case "nofollow":
+			link.Nofollow = true
+		case "ugc":
+			link.UGC = true
+		case "sponsored":
+			link.Sponsored = true
+		}
 	}
 	return link
 }
+
+// IsHyperlink reports whether the link is one a user could follow
+// to navigate to another page, that is, whether it came from an <a>
+// or <area> element.
+func (l *Link) IsHyperlink() bool {
+	return l.Element == "a" || l.Element == "area"
+}
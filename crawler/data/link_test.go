@@ -0,0 +1,86 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestGetLinks(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<head>
+<link rel="stylesheet" href="/main.css">
+<link rel="next" href="/page/2">
+</head><body>
+<header><nav><a href="/about" rel="nofollow noopener" target="_blank">About</a></nav></header>
+<main><a href="/ad" rel="Sponsored ugc" hreflang="de" type="text/html">Ad</a></main>
+<form action="/search"></form><form></form>
+<map><area href="/map" alt="Map"></map>
+</body>`))
+	if err != nil {
+		t.Fatalf("couldn't parse test data")
+	}
+
+	links := getLinks(MakeAddress("https://example.com/"), doc)
+	want := []string{"link", "a", "a", "form", "area"}
+	if len(links) != len(want) {
+		t.Fatalf("expected %d links, got %d", len(want), len(links))
+	}
+	for i, link := range links {
+		if link.Element != want[i] {
+			t.Errorf("expected link %d from %s, got %s", i, want[i], link.Element)
+		}
+	}
+
+	about := links[1]
+	if !about.Nofollow || about.UGC || about.Target != "_blank" {
+		t.Errorf("unexpected attributes for %s: %+v", about.Href, about)
+	}
+	if !about.InNav || !about.InHeader || about.InMain {
+		t.Errorf("unexpected position for %s: %+v", about.Href, about)
+	}
+
+	ad := links[2]
+	if ad.Nofollow || !ad.UGC || !ad.Sponsored || ad.Hreflang != "de" || !ad.InMain {
+		t.Errorf("unexpected attributes for %s: %+v", ad.Href, ad)
+	}
+
+	if links[0].IsHyperlink() || links[3].IsHyperlink() || !links[4].IsHyperlink() {
+		t.Errorf("only <a> and <area> links should be hyperlinks")
+	}
+}
+
+func TestLinkContext(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<body>
+<div role="navigation"><ul><li><a href="/1">One</a></li><li><a href="/2">Two</a></li></ul></div>
+<article id="post"><p class="intro lead"><a href="/3"> <img src="/3.png" alt="Three"> </a></p></article>
+</body>`))
+	if err != nil {
+		t.Fatalf("couldn't parse test data")
+	}
+
+	links := getLinks(MakeAddress("https://example.com/"), doc)
+	want := []struct {
+		landmark string
+		path     string
+		anchor   string
+	}{
+		{"nav", "html > body > div > ul > li:nth-of-type(1) > a", "One"},
+		{"nav", "html > body > div > ul > li:nth-of-type(2) > a", "Two"},
+		{"article", "html > body > article#post > p.intro.lead > a", "Three"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("expected %d links, got %d", len(want), len(links))
+	}
+	for i, link := range links {
+		if link.Position != i {
+			t.Errorf("expected position %d, got %d", i, link.Position)
+		}
+		if link.Landmark != want[i].landmark || link.DOMPath != want[i].path || link.Anchor != want[i].anchor {
+			t.Errorf("expected %v, got %+v", want[i], link)
+		}
+	}
+	if links[0].ImageLink || !links[2].ImageLink {
+		t.Errorf("only the last link should be an image link")
+	}
+}
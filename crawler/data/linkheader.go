@@ -0,0 +1,72 @@
+package data
+
+import "strings"
+
+// linkHeaderValue is a single link from an HTTP Link header, as in
+// `<https://example.com/>; rel="canonical"`. Parameter names are
+// lowercased.
+type linkHeaderValue struct {
+	href   string
+	params map[string]string
+}
+
+// parseLinkHeader parses the values of Link headers, per RFC 8288.
+// Malformed links are skipped.
+func parseLinkHeader(values []string) (links []linkHeaderValue) {
+	for _, v := range values {
+		for _, s := range splitOutsideQuotes(v, ',') {
+			s = strings.TrimSpace(s)
+			if !strings.HasPrefix(s, "<") {
+				continue
+			}
+			end := strings.Index(s, ">")
+			if end < 0 {
+				continue
+			}
+			link := linkHeaderValue{
+				href:   strings.TrimSpace(s[1:end]),
+				params: make(map[string]string),
+			}
+			for _, p := range splitOutsideQuotes(s[end+1:], ';') {
+				kv := strings.SplitN(p, "=", 2)
+				k := strings.ToLower(strings.TrimSpace(kv[0]))
+				if k == "" {
+					continue
+				}
+				var v string
+				if len(kv) == 2 {
+					v = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+				}
+				link.params[k] = v
+			}
+			links = append(links, link)
+		}
+	}
+	return
+}
+
+// rels returns the link relation types of l, lowercased.
+func (l linkHeaderValue) rels() []string {
+	return strings.Fields(strings.ToLower(l.params["rel"]))
+}
+
+// splitOutsideQuotes splits s at each sep that is not within double
+// quotes or angle brackets.
+func splitOutsideQuotes(s string, sep rune) (parts []string) {
+	var quoted, bracketed bool
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == '<' && !quoted:
+			bracketed = true
+		case r == '>' && !quoted:
+			bracketed = false
+		case r == sep && !quoted && !bracketed:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
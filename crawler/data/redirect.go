@@ -0,0 +1,93 @@
+package data
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ClientRedirect is a redirect performed by the page itself rather
+// than by the server. Type is "meta_refresh" for a <meta
+// http-equiv="refresh"> tag, or "javascript" for an assignment to
+// location found in an inline script. Delay is the number of seconds
+// before a meta refresh takes effect; it is 0 for JavaScript
+// redirects. Href is empty for a meta refresh that only reloads the
+// page.
+type ClientRedirect struct {
+	Address *Address `description:"The address of the redirect target, resolved against the page."`
+	Href    string   `description:"The URL of the redirect target as written, or empty for a meta refresh that reloads the page."`
+	Type    string   `description:"The kind of redirect: meta_refresh or javascript."`
+	Delay   int      `description:"The number of seconds before a meta refresh takes effect; 0 for JavaScript redirects."`
+}
+
+// Types of ClientRedirect.
+const (
+	RedirectMetaRefresh = "meta_refresh"
+	RedirectJavaScript  = "javascript"
+)
+
+func MakeClientRedirect(base *Address, href, typ string, delay int) *ClientRedirect {
+	redirect := &ClientRedirect{
+		Href:  href,
+		Type:  typ,
+		Delay: delay,
+	}
+	if href != "" {
+		redirect.Address = MakeAddressResolved(base, href)
+	}
+	return redirect
+}
+
+// parseRefresh parses the content of a meta refresh tag, such as
+// "5; url=https://example.com/". It reports false if content doesn't
+// begin with a delay.
+func parseRefresh(content string) (delay int, href string, ok bool) {
+	content = strings.TrimSpace(content)
+	i := strings.IndexFunc(content, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(content)
+	}
+	// Fractional delays are allowed, but only the integer part
+	// is significant.
+	n, err := strconv.Atoi(strings.SplitN(content[:i], ".", 2)[0])
+	if err != nil {
+		return 0, "", false
+	}
+
+	rest := strings.TrimLeft(content[i:], " \t\n\r;,")
+	if len(rest) >= 3 && strings.EqualFold(rest[:3], "url") {
+		if after := strings.TrimLeft(rest[3:], " \t\n\r"); strings.HasPrefix(after, "=") {
+			rest = strings.TrimLeft(after[1:], " \t\n\r")
+		}
+	}
+	if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+		if end := strings.IndexByte(rest[1:], rest[0]); end >= 0 {
+			rest = rest[1 : end+1]
+		} else {
+			rest = rest[1:]
+		}
+	}
+	return n, strings.TrimSpace(rest), true
+}
+
+// locationAssignment matches the common ways a script can send the
+// browser to a literal URL, like `window.location.href = "/new"` or
+// `location.replace('/new')`.
+var locationAssignment = regexp.MustCompile(
+	`\blocation(?:\.href)?\s*=\s*["']([^"']+)["']` +
+		`|\blocation\.(?:replace|assign)\(\s*["']([^"']+)["']\s*\)`)
+
+// findLocationAssignment returns the URL of the first JavaScript
+// redirect in script, or the empty string.
+func findLocationAssignment(script string) string {
+	m := locationAssignment.FindStringSubmatch(script)
+	if m == nil {
+		return ""
+	}
+	if m[1] != "" {
+		return m[1]
+	}
+	return m[2]
+}
@@ -0,0 +1,40 @@
+package data
+
+import "testing"
+
+func TestParseRefresh(t *testing.T) {
+	tests := []struct {
+		content string
+		delay   int
+		href    string
+		ok      bool
+	}{
+		{"0; url=https://example.com/", 0, "https://example.com/", true},
+		{"5;URL='/next'", 5, "/next", true},
+		{"3.5, url = \"/next\"", 3, "/next", true},
+		{"10", 10, "", true},
+		{"1; /next", 1, "/next", true},
+		{"url=/next", 0, "", false},
+	}
+	for _, test := range tests {
+		delay, href, ok := parseRefresh(test.content)
+		if delay != test.delay || href != test.href || ok != test.ok {
+			t.Errorf("%q: expected (%d, %q, %v), got (%d, %q, %v)",
+				test.content, test.delay, test.href, test.ok, delay, href, ok)
+		}
+	}
+}
+
+func TestFindLocationAssignment(t *testing.T) {
+	tests := map[string]string{
+		`window.location.href = "/a";`:        "/a",
+		`document.location='/b'`:              "/b",
+		`location.replace( "https://c.com" )`: "https://c.com",
+		`var location = 1; console.log(x)`:    "",
+	}
+	for script, want := range tests {
+		if got := findLocationAssignment(script); got != want {
+			t.Errorf("%q: expected %q, got %q", script, want, got)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package data
+
+import (
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Resource describes a subresource that a page loads: a script,
+// stylesheet, iframe, media file, and so on. Type is the kind of
+// resource, e.g., "script" or "stylesheet". For resources declared
+// with <link rel=preload>, Type is the value of the as attribute.
+//
+// ThirdParty is true when the resource is served from a different
+// site than the page, where a site is a registrable domain such as
+// example.com or example.co.uk.
+type Resource struct {
+	Address     *Address `description:"The address of the resource, resolved against the page."`
+	Href        string   `description:"The URL of the resource as written."`
+	Type        string   `description:"The kind of resource, like script or stylesheet."`
+	Async       bool     `description:"Whether a script has the async attribute."`
+	Defer       bool     `description:"Whether a script has the defer attribute."`
+	Module      bool     `description:"Whether a script is a module."`
+	Media       string   `description:"The media attribute."`
+	Integrity   string   `description:"The integrity attribute."`
+	Crossorigin string   `description:"The crossorigin attribute."`
+	ThirdParty  bool     `description:"Whether the resource is served from a different site than the page."`
+}
+
+func MakeResource(base *Address, href, typ string) *Resource {
+	resource := &Resource{
+		Href:    href,
+		Type:    typ,
+		Address: MakeAddressResolved(base, href),
+	}
+	if resource.Address != nil && base != nil {
+		resource.ThirdParty = site(resource.Address.Host) != site(base.Host)
+	}
+	return resource
+}
+
+// site returns the registrable domain of host. If it can't be
+// determined, as for an IP address or localhost, the host itself is
+// returned.
+func site(host string) string {
+	host = strings.ToLower(host)
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+	s, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return s
+}
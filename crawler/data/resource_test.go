@@ -0,0 +1,46 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestGetResources(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<head>
+<link rel="stylesheet" href="/main.css" media="print">
+<link rel="preload" as="font" href="https://fonts.example.net/a.woff2" crossorigin>
+<link rel="canonical" href="/">
+<script src="https://cdn.example.com/app.js" type="module" async></script>
+<script>inline()</script>
+</head><body><iframe src="https://www.youtube.com/embed/x"></iframe></body>`))
+	if err != nil {
+		t.Fatalf("couldn't parse test data")
+	}
+
+	resources := getResources(MakeAddress("https://www.example.com/"), doc)
+	want := []struct {
+		typ        string
+		thirdParty bool
+	}{
+		{"stylesheet", false},
+		{"font", true},
+		{"script", false},
+		{"iframe", true},
+	}
+	if len(resources) != len(want) {
+		t.Fatalf("expected %d resources, got %d", len(want), len(resources))
+	}
+	for i, r := range resources {
+		if r.Type != want[i].typ || r.ThirdParty != want[i].thirdParty {
+			t.Errorf("expected %v, got %+v", want[i], r)
+		}
+	}
+	if resources[0].Media != "print" {
+		t.Errorf("expected media print, got %q", resources[0].Media)
+	}
+	if !resources[2].Async || !resources[2].Module || resources[2].Defer {
+		t.Errorf("unexpected script flags: %+v", resources[2])
+	}
+}
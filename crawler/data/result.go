@@ -3,43 +3,108 @@ package data
 import (
 	"crypto/sha512"
 	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/benjaminestes/crawl/fingerprint"
 	"github.com/benjaminestes/crawl/scrape"
 	"golang.org/x/net/html"
 )
 
 type Pair struct {
-	K string
-	V string
+	K string `description:"The name of the header."`
+	V string `description:"The value of the header."`
 }
 
 type Result struct {
 	// Crawler state
-	Address *Address `json:",omitempty"`
-	Depth   int      `mode:"REQUIRED"`
+	Address  *Address `json:",omitempty" description:"The address of the page."`
+	Depth    int      `mode:"REQUIRED" description:"The number of links followed from a start URL to reach the page."`
+	Resource bool     `json:",omitempty" description:"Whether the result is for a resource, like an image or script, rather than a page."`
 
 	// Meta
-	BodyTextHash string `json:",omitempty"`
+	BodyTextHash string `json:",omitempty" description:"SHA-512 hash of the text of the body, base64-encoded."`
+
+	// Content metrics. WordCount and TextLength describe the
+	// visible text of the body, TextLength in characters;
+	// TextRatio is the size of that text in bytes relative to
+	// ContentLength, also in bytes. MainContentHash is like
+	// BodyTextHash, but for the main content of the page only,
+	// with navigation and other boilerplate removed.
+	WordCount            int     `description:"Number of words in the visible text of the body."`
+	TextLength           int     `description:"Number of characters in the visible text of the body."`
+	TextRatio            float64 `description:"Size of the visible text in bytes relative to ContentLength."`
+	MainContentWordCount int     `description:"Number of words in the main content of the page."`
+	MainContentHash      string  `json:",omitempty" description:"SHA-512 hash of the main content of the page, base64-encoded."`
+
+	// SimHash is a 64-bit SimHash of the main content of the page,
+	// or of the visible text of the body if no main content could
+	// be identified. It is stored as an int64, so that it can be
+	// compared with, e.g., BIT_COUNT(a ^ b) in BigQuery.
+	SimHash int64 `json:",omitempty" description:"64-bit SimHash of the main content of the page, for finding near-duplicates."`
+
+	// Language. HTMLLang is the lang attribute of the <html>
+	// element, and ContentLanguage the Content-Language header.
+	// DetectedLanguage is the language of the text used for
+	// SimHash, as detected statistically, with a confidence
+	// between 0 and 1.
+	HTMLLang                   string  `json:",omitempty" description:"The lang attribute of the html element."`
+	ContentLanguage            string  `json:",omitempty" description:"The Content-Language header."`
+	DetectedLanguage           string  `json:",omitempty" description:"The language of the text of the page, detected statistically."`
+	DetectedLanguageConfidence float64 `json:",omitempty" description:"Confidence in DetectedLanguage, between 0 and 1."`
 
 	// Content
-	Description string
-	Title       string
-	H1          string
-	Robots      string
-	Canonical   *Canonical  `json:",omitempty"`
-	Links       []*Link     `json:",omitempty"`
-	Hreflang    []*Hreflang `json:",omitempty"`
+	Description   string         `description:"The content of the meta description tag."`
+	Title         string         `description:"The text of the title element."`
+	H1            string         `description:"The text of the first h1 element."`
+	Robots        string         `description:"The content of the robots meta tag."`
+	Headings      []*Heading     `json:",omitempty" description:"Every h1-h6 element, in document order."`
+	HeadingCounts *HeadingCounts `json:",omitempty" description:"The number of headings of each level."`
+	Canonical     *Canonical     `json:",omitempty" description:"The canonical link element."`
+	Links         []*Link        `json:",omitempty" description:"Links found on the page."`
+	Hreflang      []*Hreflang    `json:",omitempty" description:"Hreflang annotations in link elements."`
+	HeadLinks     []*HeadLink    `json:",omitempty" description:"Pagination, AMP and alternate link elements."`
+	Images        []*Image       `json:",omitempty" description:"Images on the page."`
+	Resources     []*Resource    `json:",omitempty" description:"Scripts, stylesheets and other resources loaded by the page."`
+
+	ClientRedirects []*ClientRedirect `json:",omitempty" description:"Meta refresh and JavaScript redirects."`
+
+	// Indexing signals from the response headers, and whether the
+	// page can be indexed given all signals. IndexabilityReason
+	// says why a page can't be indexed.
+	RobotsDirectives   []*RobotsDirective `json:",omitempty" description:"Robots directives from meta tags and X-Robots-Tag headers."`
+	HeaderCanonical    *Canonical         `json:",omitempty" description:"The canonical declared in a Link header."`
+	HeaderHreflang     []*Hreflang        `json:",omitempty" description:"Hreflang annotations declared in Link headers."`
+	Indexable          bool               `description:"Whether the page can be indexed given all signals."`
+	IndexabilityReason string             `json:",omitempty" description:"Why the page cannot be indexed."`
 
 	// Response
-	Status     string   `json:",omitempty"`
-	StatusCode int      `json:",omitempty"`
-	Proto      string   `json:",omitempty"`
-	ProtoMajor int      `json:",omitempty"`
-	ProtoMinor int      `json:",omitempty"`
-	Header     []*Pair  `json:",omitempty"`
-	ResolvesTo *Address `json:",omitempty"` // In case of redirect
+	Status     string   `json:",omitempty" description:"The status line of the response."`
+	StatusCode int      `json:",omitempty" description:"The HTTP status code of the response."`
+	Proto      string   `json:",omitempty" description:"The protocol of the response."`
+	ProtoMajor int      `json:",omitempty" description:"The major version of the protocol."`
+	ProtoMinor int      `json:",omitempty" description:"The minor version of the protocol."`
+	Header     []*Pair  `json:",omitempty" description:"The headers of the response."`
+	ResolvesTo *Address `json:",omitempty" description:"The target of a redirect."` // In case of redirect
+
+	// ContentLength is the number of bytes in the response body,
+	// or the value of the Content-Length header if the body was
+	// not read. Without that header, the body is always read, or
+	// for a HEAD request, ContentLength is 0. ResponseTimeMs is the
+	// time in milliseconds from sending the request to receiving the
+	// response headers.
+	ContentType    string `json:",omitempty" description:"The Content-Type header."`
+	ContentLength  int64  `description:"Number of bytes in the response body."`
+	ResponseTimeMs int64  `description:"Milliseconds from sending the request to receiving the response headers."`
+
+	// WARCFilename and WARCOffset locate the response record for
+	// the result, if the crawl was archived in a WARC file.
+	WARCFilename string `json:",omitempty" description:"The name of the WARC file holding the response."`
+	WARCOffset   int64  `json:",omitempty" description:"The offset of the response record in the WARC file."`
 }
 
 func MakeResult(rawurl string, depth int, resp *http.Response) *Result {
@@ -56,18 +121,50 @@ func MakeResult(rawurl string, depth int, resp *http.Response) *Result {
 	return result
 }
 
+// MakeResourceResult is like MakeResult, but for a resource such as
+// an image or script rather than a page. Only the response is
+// described; the body is never parsed, so no links are extracted.
+func MakeResourceResult(rawurl string, depth int, resp *http.Response) *Result {
+	result := &Result{
+		Address:  MakeAddress(rawurl),
+		Depth:    depth,
+		Resource: true,
+	}
+
+	if resp != nil {
+		hydrateHeader(result, resp)
+		if resp.Request == nil || resp.Request.Method != "HEAD" {
+			result.ContentLength, _ = io.Copy(ioutil.Discard, resp.Body)
+		}
+		hydrateResolvesTo(result, resp)
+	}
+	return result
+}
+
 func (r *Result) hydrate(resp *http.Response) {
 	hydrateHeader(r, resp)
+	hydrateHeaderDirectives(r, resp)
 
-	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
-		doc, err := html.Parse(resp.Body)
-		if err != nil {
-			return
+	if strings.HasPrefix(r.ContentType, "text/html") {
+		body := &countingReader{r: resp.Body}
+		doc, err := html.Parse(body)
+		r.ContentLength = body.n
+		if err == nil {
+			hydrateHTMLContent(r, doc)
 		}
-		hydrateHTMLContent(r, doc)
+	} else if resp.ContentLength < 0 {
+		// Without a Content-Length header, the body must be
+		// read to know its length.
+		r.ContentLength, _ = io.Copy(ioutil.Discard, resp.Body)
 	}
 
-	// If the result doesn't redirect, we say it resolves to itself.
+	hydrateResolvesTo(r, resp)
+	hydrateIndexability(r)
+}
+
+// hydrateResolvesTo records where the result redirects to. If the
+// result doesn't redirect, we say it resolves to itself.
+func hydrateResolvesTo(r *Result, resp *http.Response) {
 	r.ResolvesTo = r.Address
 	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
 		loc := resp.Header.Get("Location")
@@ -75,6 +172,18 @@ func (r *Result) hydrate(resp *http.Response) {
 	}
 }
 
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
 func hydrateHeader(r *Result, resp *http.Response) {
 	for k := range resp.Header {
 		r.Header = append(r.Header, &Pair{k, resp.Header.Get(k)})
@@ -84,10 +193,87 @@ func hydrateHeader(r *Result, resp *http.Response) {
 	r.Proto = resp.Proto
 	r.ProtoMajor = resp.ProtoMajor
 	r.ProtoMinor = resp.ProtoMinor
+	r.ContentType = resp.Header.Get("Content-Type")
+	r.ContentLanguage = resp.Header.Get("Content-Language")
+	if resp.ContentLength >= 0 {
+		r.ContentLength = resp.ContentLength
+	}
+}
+
+// hydrateHeaderDirectives records the indexing directives in the
+// X-Robots-Tag and Link headers of resp.
+func hydrateHeaderDirectives(r *Result, resp *http.Response) {
+	for _, v := range resp.Header["X-Robots-Tag"] {
+		r.RobotsDirectives = append(r.RobotsDirectives, MakeRobotsDirectives("header", v)...)
+	}
+
+	for _, link := range parseLinkHeader(resp.Header["Link"]) {
+		rels := link.rels()
+		switch {
+		case hasToken(rels, "canonical"):
+			if r.HeaderCanonical == nil {
+				r.HeaderCanonical = MakeCanonical(r.Address, link.href)
+			}
+		case hasToken(rels, "alternate") && link.params["hreflang"] != "":
+			r.HeaderHreflang = append(r.HeaderHreflang,
+				MakeHreflang(r.Address, link.href, link.params["hreflang"]))
+		}
+	}
+}
+
+// Reasons a page may not be indexable.
+const (
+	ReasonStatus              = "non_200_status"
+	ReasonNoindexMeta         = "noindex_meta"
+	ReasonNoindexHeader       = "noindex_header"
+	ReasonCanonicalizedHTML   = "canonicalized_html"
+	ReasonCanonicalizedHeader = "canonicalized_header"
+	ReasonBlockedByRobotsTxt  = "blocked_robots_txt"
+)
+
+// hydrateIndexability decides whether r can be indexed by a crawler
+// that follows all directives that aren't scoped to a particular
+// user agent. It must be called after all other hydration.
+func hydrateIndexability(r *Result) {
+	switch {
+	case r.StatusCode != http.StatusOK:
+		r.IndexabilityReason = ReasonStatus
+	case isNoindex(r.RobotsDirectives, "meta"):
+		r.IndexabilityReason = ReasonNoindexMeta
+	case isNoindex(r.RobotsDirectives, "header"):
+		r.IndexabilityReason = ReasonNoindexHeader
+	case isCanonicalized(r.Address, r.Canonical):
+		r.IndexabilityReason = ReasonCanonicalizedHTML
+	case isCanonicalized(r.Address, r.HeaderCanonical):
+		r.IndexabilityReason = ReasonCanonicalizedHeader
+	default:
+		r.Indexable = true
+	}
+}
+
+// isNoindex reports whether any of directives from source that apply
+// to all user agents includes noindex.
+func isNoindex(directives []*RobotsDirective, source string) bool {
+	for _, d := range directives {
+		if d.Source == source && d.UserAgent == "" && d.Noindex {
+			return true
+		}
+	}
+	return false
+}
+
+// isCanonicalized reports whether c declares a canonical address
+// other than addr.
+func isCanonicalized(addr *Address, c *Canonical) bool {
+	if c == nil || c.Href == "" || c.Address == nil || addr == nil {
+		return false
+	}
+	return c.Address.Full != addr.Full
 }
 
 func hydrateHTMLContent(r *Result, doc *html.Node) {
 	r.Title = scrape.Text(scrape.Query("title", nil, doc))
+	r.HTMLLang = strings.TrimSpace(scrape.Attribute("lang", scrape.Query("html", nil, doc)))
 	r.H1 = scrape.Text(scrape.Query("h1", nil, doc))
 	r.Description = scrape.Attribute(
 		"content",
@@ -106,12 +292,46 @@ func hydrateHTMLContent(r *Result, doc *html.Node) {
 			},
 			doc,
 		))
+	r.RobotsDirectives = append(r.RobotsDirectives, getRobotsDirectives(doc)...)
+	r.Headings, r.HeadingCounts = getHeadings(doc)
 	r.Canonical = getCanonical(r.Address, doc)
 	r.Hreflang = getHreflang(r.Address, doc)
+	r.HeadLinks = getHeadLinks(r.Address, doc)
 	r.Links = getLinks(r.Address, doc)
+	r.ClientRedirects = getClientRedirects(r.Address, doc)
+	r.Images = getImages(r.Address, doc)
+	r.Resources = getResources(r.Address, doc)
 
-	sum := sha512.Sum512([]byte(scrape.Text(scrape.Query("body", nil, doc))))
+	body := scrape.Query("body", nil, doc)
+	sum := sha512.Sum512([]byte(scrape.Text(body)))
 	r.BodyTextHash = base64.StdEncoding.EncodeToString(sum[:])
+
+	hydrateContentMetrics(r, body)
+}
+
+// hydrateContentMetrics measures the visible text of body, identifies
+// and hashes its main content, and detects its language.
+func hydrateContentMetrics(r *Result, body *html.Node) {
+	if body == nil {
+		return
+	}
+	text := scrape.VisibleText(body)
+	r.WordCount = len(strings.Fields(text))
+	r.TextLength = utf8.RuneCountInString(text)
+	// The ratio compares bytes with bytes, so text in a
+	// multibyte encoding isn't undercounted against its markup.
+	if r.ContentLength > 0 {
+		r.TextRatio = float64(len(text)) / float64(r.ContentLength)
+	}
+
+	if main := scrape.MainContent(body); main != nil {
+		text = scrape.VisibleText(main)
+		r.MainContentWordCount = len(strings.Fields(text))
+		sum := sha512.Sum512([]byte(text))
+		r.MainContentHash = base64.StdEncoding.EncodeToString(sum[:])
+	}
+	r.SimHash = int64(fingerprint.SimHash(text))
+	r.DetectedLanguage, r.DetectedLanguageConfidence = detectLanguage(text)
 }
 
 func getCanonical(base *Address, n *html.Node) (c *Canonical) {
@@ -121,16 +341,80 @@ func getCanonical(base *Address, n *html.Node) (c *Canonical) {
 	return MakeCanonical(base, href)
 }
 
+// getRobotsDirectives returns the directives of every robots meta
+// tag in n, whether for all crawlers (name="robots") or for a
+// specific one (e.g., name="googlebot").
+func getRobotsDirectives(n *html.Node) (directives []*RobotsDirective) {
+	for _, meta := range scrape.NodesByTagName("meta", n) {
+		name := strings.ToLower(strings.TrimSpace(scrape.Attribute("name", meta)))
+		if !isRobotsMetaName(name) {
+			continue
+		}
+		userAgent := name
+		if name == "robots" {
+			userAgent = ""
+		}
+		for _, d := range MakeRobotsDirectives("meta", scrape.Attribute("content", meta)) {
+			if d.UserAgent == "" {
+				d.UserAgent = userAgent
+			}
+			directives = append(directives, d)
+		}
+	}
+	return
+}
+
+// getClientRedirects returns the meta refresh tags in n, followed by
+// any JavaScript redirects found in inline scripts.
+func getClientRedirects(base *Address, n *html.Node) (redirects []*ClientRedirect) {
+	for _, meta := range scrape.NodesByTagName("meta", n) {
+		if !strings.EqualFold(scrape.Attribute("http-equiv", meta), "refresh") {
+			continue
+		}
+		delay, href, ok := parseRefresh(scrape.Attribute("content", meta))
+		if !ok {
+			continue
+		}
+		redirects = append(redirects, MakeClientRedirect(base, href, RedirectMetaRefresh, delay))
+	}
+
+	for _, script := range scrape.NodesByTagName("script", n) {
+		if _, ok := scrape.LookupAttribute("src", script); ok {
+			continue
+		}
+		if href := findLocationAssignment(scrape.Text(script)); href != "" {
+			redirects = append(redirects, MakeClientRedirect(base, href, RedirectJavaScript, 0))
+		}
+	}
+	return
+}
+
+// getHeadings returns every h1–h6 in n in document order, along with
+// the number of headings found at each level.
+func getHeadings(n *html.Node) (headings []*Heading, counts *HeadingCounts) {
+	counts = &HeadingCounts{}
+	nodes := scrape.NodesByTagNames([]string{"h1", "h2", "h3", "h4", "h5", "h6"}, n)
+	for i, h := range nodes {
+		// The tag name is one of h1–h6, so the level is its
+		// second character.
+		level := int(h.Data[1] - '0')
+		text := strings.Join(strings.Fields(scrape.Text(h)), " ")
+		headings = append(headings, MakeHeading(level, text, i))
+		counts.add(level)
+	}
+	return
+}
+
 // FIXME: Should get the same URL resolving treatment as links
 func getHreflang(base *Address, n *html.Node) (hreflang []*Hreflang) {
-	nodes := scrape.QueryAll("link", map[string]string{
-		"rel": "alternate",
-	}, n)
-
-	for _, n := range nodes {
+	for _, n := range scrape.NodesByTagName("link", n) {
+		rels := strings.Fields(strings.ToLower(scrape.Attribute("rel", n)))
+		if !hasToken(rels, "alternate") {
+			continue
+		}
 		lang := scrape.Attribute("hreflang", n)
 		href := scrape.Attribute("href", n)
-		if href != "" {
+		if href != "" && lang != "" {
 			hreflang = append(hreflang, MakeHreflang(base, href, lang))
 		}
 	}
@@ -138,17 +422,379 @@ func getHreflang(base *Address, n *html.Node) (hreflang []*Hreflang) {
 	return
 }
 
+// getHeadLinks returns the pagination, AMP, and alternate <link>
+// elements in n. Alternates with hreflang are reported by
+// getHreflang instead.
+func getHeadLinks(base *Address, n *html.Node) (headLinks []*HeadLink) {
+	for _, n := range scrape.NodesByTagName("link", n) {
+		href := scrape.Attribute("href", n)
+		if href == "" {
+			continue
+		}
+		for _, rel := range strings.Fields(strings.ToLower(scrape.Attribute("rel", n))) {
+			switch rel {
+			case "previous":
+				rel = "prev"
+			case "next", "prev", "amphtml":
+			case "alternate":
+				if scrape.Attribute("hreflang", n) != "" {
+					continue
+				}
+			default:
+				continue
+			}
+			headLink := MakeHeadLink(base, href, rel)
+			headLink.Media = scrape.Attribute("media", n)
+			headLink.Type = strings.ToLower(scrape.Attribute("type", n))
+			headLinks = append(headLinks, headLink)
+		}
+	}
+	return
+}
+
+// linkRels are the rel values of <link> elements that are recorded
+// as links. Other <link> elements, such as stylesheets, describe
+// resources rather than other pages.
+var linkRels = map[string]bool{
+	"alternate": true,
+	"amphtml":   true,
+	"author":    true,
+	"canonical": true,
+	"help":      true,
+	"license":   true,
+	"next":      true,
+	"prev":      true,
+	"search":    true,
+}
+
+// landmarkElements are the elements that define landmark regions of
+// a page.
+var landmarkElements = map[string]bool{
+	"nav":     true,
+	"header":  true,
+	"footer":  true,
+	"aside":   true,
+	"main":    true,
+	"article": true,
+}
+
+// landmarkRoles maps ARIA landmark roles to the equivalent element.
+var landmarkRoles = map[string]string{
+	"navigation":    "nav",
+	"banner":        "header",
+	"contentinfo":   "footer",
+	"complementary": "aside",
+	"main":          "main",
+	"article":       "article",
+}
+
+// linkContext describes where in the tree a link was found. It is
+// built up while walking down the tree in getLinks.
+type linkContext struct {
+	landmark string
+	inNav    bool
+	inHeader bool
+	inFooter bool
+	inMain   bool
+}
+
+// enter returns the context of the children of the element n.
+func (ctx linkContext) enter(n *html.Node) linkContext {
+	if landmarkElements[n.Data] {
+		ctx.landmark = n.Data
+	} else if l, ok := landmarkRoles[scrape.Attribute("role", n)]; ok {
+		ctx.landmark = l
+	}
+
+	switch n.Data {
+	case "nav":
+		ctx.inNav = true
+	case "header":
+		ctx.inHeader = true
+	case "footer":
+		ctx.inFooter = true
+	case "main":
+		ctx.inMain = true
+	}
+	return ctx
+}
+
+// domPaths computes CSS-like paths to elements on demand. The paths
+// of ancestors and the positions of siblings are remembered, so that
+// no element is visited more than a few times however many links
+// share its ancestors.
+type domPaths struct {
+	paths     map[*html.Node]string
+	positions map[*html.Node]int
+}
+
+func newDOMPaths() *domPaths {
+	return &domPaths{
+		paths:     make(map[*html.Node]string),
+		positions: make(map[*html.Node]int),
+	}
+}
+
+// path returns the path from the root element to the element n.
+func (d *domPaths) path(n *html.Node) string {
+	if p, ok := d.paths[n]; ok {
+		return p
+	}
+	p := d.segment(n)
+	if n.Parent != nil && n.Parent.Type == html.ElementNode {
+		p = d.path(n.Parent) + " > " + p
+	}
+	d.paths[n] = p
+	return p
+}
+
+// segment describes the element n in a CSS-like selector. An id is
+// used if there is one. Otherwise, classes are included, and
+// :nth-of-type if n has siblings with the same tag name.
+func (d *domPaths) segment(n *html.Node) string {
+	seg := n.Data
+	if id := scrape.Attribute("id", n); id != "" {
+		return seg + "#" + id
+	}
+	for _, class := range scrape.Classes(n) {
+		seg += "." + class
+	}
+	if index := d.position(n); index > 0 {
+		seg += fmt.Sprintf(":nth-of-type(%d)", index)
+	}
+	return seg
+}
+
+// position returns the index of n among the siblings with the same
+// tag name, counting from 1, or 0 if it has no such siblings. The
+// positions of all of the siblings of n are found at once.
+func (d *domPaths) position(n *html.Node) int {
+	if index, ok := d.positions[n]; ok || n.Parent == nil {
+		return index
+	}
+	counts := make(map[string]int)
+	for sib := n.Parent.FirstChild; sib != nil; sib = sib.NextSibling {
+		if sib.Type == html.ElementNode {
+			counts[sib.Data]++
+			d.positions[sib] = counts[sib.Data]
+		}
+	}
+	for sib := n.Parent.FirstChild; sib != nil; sib = sib.NextSibling {
+		if sib.Type == html.ElementNode && counts[sib.Data] == 1 {
+			d.positions[sib] = 0
+		}
+	}
+	return d.positions[n]
+}
+
+// getLinks returns a Link for every <a>, <area>, navigational <link>,
+// <form>, and <iframe> in n that refers to a URL. Links are in
+// document order.
 func getLinks(base *Address, n *html.Node) (links []*Link) {
-	els := scrape.NodesByTagName("a", n)
-	for _, a := range els {
-		href := scrape.Attribute("href", a)
-		link := MakeLink(
-			base,
-			href,
-			scrape.Text(a),
-			scrape.Attribute("rel", a) == "nofollow", // FIXME: Trim whitespace?
-		)
-		links = append(links, link)
+	paths := newDOMPaths()
+	var walk func(*html.Node, linkContext)
+	walk = func(n *html.Node, ctx linkContext) {
+		if n.Type == html.ElementNode {
+			ctx = ctx.enter(n)
+			if link := makeLinkFromNode(base, n); link != nil {
+				link.Position = len(links)
+				link.Landmark = ctx.landmark
+				link.DOMPath = paths.path(n)
+				link.InNav = ctx.inNav
+				link.InHeader = ctx.inHeader
+				link.InFooter = ctx.inFooter
+				link.InMain = ctx.inMain
+				links = append(links, link)
+			}
+		}
+		for next := n.FirstChild; next != nil; next = next.NextSibling {
+			walk(next, ctx)
+		}
 	}
+	walk(n, linkContext{})
 	return links
 }
+
+// makeLinkFromNode returns a Link describing the element el, or nil
+// if el isn't a link.
+func makeLinkFromNode(base *Address, el *html.Node) *Link {
+	var href, anchor string
+	var image *html.Node
+	switch el.Data {
+	case "a":
+		href = scrape.Attribute("href", el)
+		anchor = scrape.Text(el)
+		image = scrape.Query("img", nil, el)
+		// For a link containing only an image, the alt text
+		// of the image serves as the anchor text.
+		if strings.TrimSpace(anchor) == "" && image != nil {
+			anchor = scrape.Attribute("alt", image)
+		}
+	case "area":
+		href = scrape.Attribute("href", el)
+		anchor = scrape.Attribute("alt", el)
+	case "link":
+		if !isNavigationalLink(el) {
+			return nil
+		}
+		href = scrape.Attribute("href", el)
+		anchor = scrape.Attribute("title", el)
+	case "form":
+		if _, ok := scrape.LookupAttribute("action", el); !ok {
+			return nil
+		}
+		href = scrape.Attribute("action", el)
+	case "iframe":
+		if _, ok := scrape.LookupAttribute("src", el); !ok {
+			return nil
+		}
+		href = scrape.Attribute("src", el)
+		anchor = scrape.Attribute("title", el)
+	default:
+		return nil
+	}
+
+	link := MakeLink(base, href, anchor, scrape.Attribute("rel", el))
+	link.Element = el.Data
+	link.Target = scrape.Attribute("target", el)
+	link.Hreflang = scrape.Attribute("hreflang", el)
+	link.Type = scrape.Attribute("type", el)
+	link.ImageLink = image != nil
+	return link
+}
+
+// isNavigationalLink reports whether the <link> element n refers to
+// another page, rather than to a resource used by the page.
+func isNavigationalLink(n *html.Node) bool {
+	if _, ok := scrape.LookupAttribute("href", n); !ok {
+		return false
+	}
+	rels := strings.Fields(strings.ToLower(scrape.Attribute("rel", n)))
+	if hasToken(rels, "stylesheet") {
+		return false
+	}
+	for _, rel := range rels {
+		if linkRels[rel] {
+			return true
+		}
+	}
+	return false
+}
+
+// imageAttributes are the attributes of <img> and <source> elements
+// that may hold image URLs, including those commonly used by
+// lazy-loading scripts.
+var imageAttributes = []struct {
+	name   string
+	srcset bool
+}{
+	{"src", false},
+	{"srcset", true},
+	{"data-src", false},
+	{"data-srcset", true},
+}
+
+// getImages returns an Image for every image candidate of every <img>
+// element, and of every <source> element within a <picture>.
+func getImages(base *Address, n *html.Node) (images []*Image) {
+	els := scrape.NodesByTagNames([]string{"img", "source"}, n)
+	for _, el := range els {
+		img := el
+		if el.Data == "source" {
+			if el.Parent == nil || el.Parent.Data != "picture" {
+				continue
+			}
+			img = scrape.Query("img", nil, el.Parent)
+		}
+		alt, hasAlt := scrape.LookupAttribute("alt", img)
+
+		for _, attr := range imageAttributes {
+			val, ok := scrape.LookupAttribute(attr.name, el)
+			if !ok {
+				continue
+			}
+			var candidates []srcsetCandidate
+			if attr.srcset {
+				candidates = parseSrcset(val)
+			} else if val = strings.TrimSpace(val); val != "" {
+				candidates = []srcsetCandidate{{val, ""}}
+			}
+			for _, c := range candidates {
+				image := MakeImage(base, c.href, el.Data, attr.name, c.descriptor)
+				image.Alt = alt
+				image.HasAlt = hasAlt
+				image.Width = scrape.Attribute("width", el)
+				image.Height = scrape.Attribute("height", el)
+				image.Loading = scrape.Attribute("loading", img)
+				images = append(images, image)
+			}
+		}
+	}
+	return
+}
+
+// getResources returns a Resource for every script, stylesheet,
+// preloaded file, iframe, and embedded media file loaded by the page
+// n. Images are reported separately by getImages.
+func getResources(base *Address, n *html.Node) (resources []*Resource) {
+	els := scrape.NodesByTagNames([]string{
+		"script", "link", "iframe", "video", "audio", "source", "track", "embed", "object",
+	}, n)
+	for _, el := range els {
+		var href, typ string
+		rel := strings.Fields(strings.ToLower(scrape.Attribute("rel", el)))
+		switch el.Data {
+		case "link":
+			href = scrape.Attribute("href", el)
+			switch {
+			case hasToken(rel, "stylesheet"):
+				typ = "stylesheet"
+			case hasToken(rel, "modulepreload"):
+				typ = "script"
+			case hasToken(rel, "preload"):
+				typ = strings.ToLower(scrape.Attribute("as", el))
+			}
+		case "object":
+			href = scrape.Attribute("data", el)
+			typ = el.Data
+		case "source":
+			// Sources within <picture> are images.
+			if el.Parent == nil || (el.Parent.Data != "video" && el.Parent.Data != "audio") {
+				continue
+			}
+			href = scrape.Attribute("src", el)
+			typ = el.Parent.Data
+		default:
+			href = scrape.Attribute("src", el)
+			typ = el.Data
+		}
+		if href = strings.TrimSpace(href); href == "" || typ == "" {
+			continue
+		}
+
+		resource := MakeResource(base, href, typ)
+		switch el.Data {
+		case "script":
+			_, resource.Async = scrape.LookupAttribute("async", el)
+			_, resource.Defer = scrape.LookupAttribute("defer", el)
+			resource.Module = strings.ToLower(scrape.Attribute("type", el)) == "module"
+		case "link":
+			resource.Module = hasToken(rel, "modulepreload")
+			resource.Media = scrape.Attribute("media", el)
+		}
+		resource.Integrity = scrape.Attribute("integrity", el)
+		resource.Crossorigin = scrape.Attribute("crossorigin", el)
+		resources = append(resources, resource)
+	}
+	return
+}
+
+// hasToken reports whether tok is among tokens.
+func hasToken(tokens []string, tok string) bool {
+	for _, t := range tokens {
+		if t == tok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package data
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestContentLength(t *testing.T) {
+	tests := []struct {
+		contentType   string
+		contentLength int64
+		method        string
+		want          int64
+	}{
+		{"text/html", -1, "GET", 11},
+		{"application/pdf", 100, "GET", 100},
+		{"application/pdf", -1, "GET", 11},
+		{"image/png", -1, "HEAD", 0},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, "https://example.com/", nil)
+		resp := &http.Response{
+			StatusCode:    200,
+			Header:        http.Header{"Content-Type": {tt.contentType}},
+			ContentLength: tt.contentLength,
+			Body:          ioutil.NopCloser(strings.NewReader("<p>body</p>")),
+			Request:       req,
+		}
+		var r *Result
+		if tt.method == "HEAD" {
+			r = MakeResourceResult("https://example.com/", 0, resp)
+		} else {
+			r = MakeResult("https://example.com/", 0, resp)
+		}
+		if r.ContentLength != tt.want {
+			t.Errorf("%s %s with Content-Length %d: got %d, want %d",
+				tt.method, tt.contentType, tt.contentLength, r.ContentLength, tt.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package data
+
+import (
+	"strconv"
+	"strings"
+)
+
+// RobotsDirective is a set of indexing directives that apply to a
+// page, such as those in an X-Robots-Tag header or a robots meta
+// tag. Source is where the directives were found: "header" or
+// "meta". UserAgent is the crawler the directives are scoped to,
+// lowercased, or empty if they apply to all crawlers.  Value is the
+// directives as written.
+//
+// MaxSnippet and MaxVideoPreview are -1 when no limit is given, which
+// is also the meaning of an explicit -1.
+type RobotsDirective struct {
+	Source           string `description:"Where the directives were found: header or meta."`
+	UserAgent        string `description:"The crawler the directives are scoped to, lowercased, or empty if they apply to all crawlers."`
+	Value            string `description:"The directives as written."`
+	Noindex          bool   `description:"Whether the directives include noindex or none."`
+	Nofollow         bool   `description:"Whether the directives include nofollow or none."`
+	Noarchive        bool   `description:"Whether the directives include noarchive."`
+	Nosnippet        bool   `description:"Whether the directives include nosnippet."`
+	Noimageindex     bool   `description:"Whether the directives include noimageindex."`
+	Notranslate      bool   `description:"Whether the directives include notranslate."`
+	MaxSnippet       int    `description:"The max-snippet limit in characters, or -1 for no limit."`
+	MaxImagePreview  string `description:"The max-image-preview setting: none, standard, or large."`
+	MaxVideoPreview  int    `description:"The max-video-preview limit in seconds, or -1 for no limit."`
+	UnavailableAfter string `description:"The unavailable_after date as written."`
+}
+
+func makeRobotsDirective(source, userAgent string) *RobotsDirective {
+	return &RobotsDirective{
+		Source:          source,
+		UserAgent:       userAgent,
+		MaxSnippet:      -1,
+		MaxVideoPreview: -1,
+	}
+}
+
+// robotsParameters are directives that take a value after a colon,
+// and so can't be mistaken for a user agent.
+var robotsParameters = map[string]bool{
+	"max-snippet":       true,
+	"max-image-preview": true,
+	"max-video-preview": true,
+	"unavailable_after": true,
+}
+
+// MakeRobotsDirectives parses value, which may scope directives to
+// user agents as in "googlebot: noindex, otherbot: nofollow", and
+// returns one RobotsDirective for each user agent. Directives before
+// any user agent apply to all crawlers. Because a date may contain
+// commas, the rest of value after unavailable_after is its date.
+func MakeRobotsDirectives(source, value string) (directives []*RobotsDirective) {
+	var d *RobotsDirective
+	var tokens []string
+	flush := func() {
+		if d != nil {
+			d.Value = strings.Join(tokens, ", ")
+			directives = append(directives, d)
+		}
+		tokens = nil
+	}
+
+	for rest := value; rest != ""; {
+		tok := rest
+		if i := strings.Index(rest, ","); i >= 0 {
+			tok, rest = rest[:i], rest[i+1:]
+		} else {
+			rest = ""
+		}
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		if i := strings.Index(tok, ":"); i >= 0 {
+			name := strings.ToLower(strings.TrimSpace(tok[:i]))
+			param := strings.TrimSpace(tok[i+1:])
+			// A prefix followed by a date, as in the time of
+			// "Friday, 25-Jun-10 15:00:00 PST", isn't a user
+			// agent.
+			if !robotsParameters[name] && !isRobotsDate(param) {
+				flush()
+				d = makeRobotsDirective(source, name)
+				tok = param
+				if tok == "" {
+					continue
+				}
+			}
+		}
+		if robotsDirectiveName(tok) == "unavailable_after" && rest != "" {
+			tok = strings.TrimSpace(tok + "," + rest)
+			rest = ""
+		}
+		if d == nil {
+			d = makeRobotsDirective(source, "")
+		}
+		tokens = append(tokens, tok)
+		d.apply(tok)
+	}
+	flush()
+	return
+}
+
+// robotsDirectiveName returns the lowercased name of the directive
+// tok, which is the part before any colon.
+func robotsDirectiveName(tok string) string {
+	if i := strings.Index(tok, ":"); i >= 0 {
+		tok = tok[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(tok))
+}
+
+// isRobotsDate reports whether s looks like the start of a date or
+// time rather than of a directive. No directive begins with a digit.
+func isRobotsDate(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
+
+// apply sets the fields of d that correspond to the directive tok.
+func (d *RobotsDirective) apply(tok string) {
+	var param string
+	if i := strings.Index(tok, ":"); i >= 0 {
+		param = strings.TrimSpace(tok[i+1:])
+	}
+	switch robotsDirectiveName(tok) {
+	case "noindex":
+		d.Noindex = true
+	case "nofollow":
+		d.Nofollow = true
+	case "none":
+		d.Noindex = true
+		d.Nofollow = true
+	case "noarchive", "nocache":
+		d.Noarchive = true
+	case "nosnippet":
+		d.Nosnippet = true
+	case "noimageindex":
+		d.Noimageindex = true
+	case "notranslate":
+		d.Notranslate = true
+	case "max-snippet":
+		if n, err := strconv.Atoi(param); err == nil {
+			d.MaxSnippet = n
+		}
+	case "max-image-preview":
+		d.MaxImagePreview = strings.ToLower(param)
+	case "max-video-preview":
+		if n, err := strconv.Atoi(param); err == nil {
+			d.MaxVideoPreview = n
+		}
+	case "unavailable_after":
+		d.UnavailableAfter = param
+	}
+}
+
+// robotsMetaNames are the names of meta tags that hold robots
+// directives: "robots", which applies to all crawlers, and the names
+// of crawlers documented as reading a meta tag of their own.
+var robotsMetaNames = map[string]bool{
+	"robots":          true,
+	"googlebot":       true,
+	"googlebot-news":  true,
+	"googlebot-image": true,
+	"googlebot-video": true,
+	"adsbot-google":   true,
+	"bingbot":         true,
+	"msnbot":          true,
+	"slurp":           true,
+	"yandex":          true,
+	"baiduspider":     true,
+	"duckduckbot":     true,
+	"applebot":        true,
+	"teoma":           true,
+}
+
+// isRobotsMetaName reports whether a meta tag with the given name
+// holds robots directives.
+func isRobotsMetaName(name string) bool {
+	return robotsMetaNames[name]
+}
@@ -0,0 +1,131 @@
+package data
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestMakeRobotsDirectives(t *testing.T) {
+	directives := MakeRobotsDirectives("header",
+		"noindex, googlebot: nofollow, max-snippet:20, otherbot: none")
+	if len(directives) != 3 {
+		t.Fatalf("expected 3 directives, got %d", len(directives))
+	}
+
+	all, google, other := directives[0], directives[1], directives[2]
+	if all.UserAgent != "" || !all.Noindex || all.Nofollow {
+		t.Errorf("unexpected directive for all user agents: %+v", all)
+	}
+	if all.MaxSnippet != -1 || all.MaxVideoPreview != -1 {
+		t.Errorf("expected no limits for all user agents: %+v", all)
+	}
+	if google.UserAgent != "googlebot" || google.Noindex || !google.Nofollow || google.MaxSnippet != 20 {
+		t.Errorf("unexpected directive for googlebot: %+v", google)
+	}
+	if google.Value != "nofollow, max-snippet:20" {
+		t.Errorf("unexpected value for googlebot: %q", google.Value)
+	}
+	if other.UserAgent != "otherbot" || !other.Noindex || !other.Nofollow {
+		t.Errorf("unexpected directive for otherbot: %+v", other)
+	}
+}
+
+func TestUnavailableAfter(t *testing.T) {
+	tests := []struct {
+		value     string
+		userAgent string
+		date      string
+	}{
+		{"noindex, unavailable_after: Friday, 25-Jun-10 15:00:00 PST", "", "Friday, 25-Jun-10 15:00:00 PST"},
+		{"noindex, googlebot: unavailable_after: Friday, 25-Jun-10 15:00:00 PST", "googlebot", "Friday, 25-Jun-10 15:00:00 PST"},
+		{"noindex, unavailable_after: 2010-06-25T15:00:00-08:00", "", "2010-06-25T15:00:00-08:00"},
+		{"noindex, googlebot: unavailable_after: 2010-06-25T15:00:00-08:00", "googlebot", "2010-06-25T15:00:00-08:00"},
+	}
+	for _, test := range tests {
+		directives := MakeRobotsDirectives("header", test.value)
+		d := directives[len(directives)-1]
+		if d.UserAgent != test.userAgent || d.UnavailableAfter != test.date {
+			t.Errorf("%q: expected user agent %q and date %q, got %q and %q",
+				test.value, test.userAgent, test.date, d.UserAgent, d.UnavailableAfter)
+		}
+		if test.userAgent == "" && len(directives) != 1 {
+			t.Errorf("%q: expected 1 directive, got %d", test.value, len(directives))
+		}
+		if test.userAgent != "" && len(directives) != 2 {
+			t.Errorf("%q: expected 2 directives, got %d", test.value, len(directives))
+		}
+	}
+}
+
+func TestGetRobotsDirectives(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<head>
+<meta name="robots" content="noarchive, max-image-preview:large">
+<meta name="Googlebot" content="nosnippet, noimageindex">
+<meta name="description" content="noindex">
+<meta name="chatbot" content="noindex">
+</head>`))
+	if err != nil {
+		t.Fatalf("couldn't parse test data")
+	}
+
+	directives := getRobotsDirectives(doc)
+	if len(directives) != 2 {
+		t.Fatalf("expected 2 directives, got %d", len(directives))
+	}
+	all, google := directives[0], directives[1]
+	if all.Source != "meta" || all.UserAgent != "" || !all.Noarchive || all.MaxImagePreview != "large" {
+		t.Errorf("unexpected directive for all user agents: %+v", all)
+	}
+	if google.UserAgent != "googlebot" || !google.Nosnippet || !google.Noimageindex || google.Noindex {
+		t.Errorf("unexpected directive for googlebot: %+v", google)
+	}
+}
+
+func TestParseLinkHeader(t *testing.T) {
+	links := parseLinkHeader([]string{
+		`<https://example.com/a,b>; rel="canonical", </de>; rel=alternate; hreflang="de"`,
+	})
+	if len(links) != 2 {
+		t.Fatalf("expected 2 links, got %d", len(links))
+	}
+	if links[0].href != "https://example.com/a,b" || links[0].params["rel"] != "canonical" {
+		t.Errorf("unexpected first link: %+v", links[0])
+	}
+	if links[1].href != "/de" || links[1].params["hreflang"] != "de" {
+		t.Errorf("unexpected second link: %+v", links[1])
+	}
+}
+
+func TestIndexability(t *testing.T) {
+	tests := []struct {
+		status int
+		header http.Header
+		body   string
+		reason string
+	}{
+		{200, http.Header{}, "", ""},
+		{404, http.Header{}, "", ReasonStatus},
+		{200, http.Header{"X-Robots-Tag": {"googlebot: noindex"}}, "", ""},
+		{200, http.Header{"X-Robots-Tag": {"noindex"}}, "", ReasonNoindexHeader},
+		{200, http.Header{"Link": {`<https://example.com/other>; rel="canonical"`}}, "", ReasonCanonicalizedHeader},
+		{200, http.Header{"Link": {`<https://example.com/page>; rel="canonical"`}}, "", ""},
+		{200, http.Header{"Content-Type": {"text/html"}}, `<meta name="robots" content="NOINDEX">`, ReasonNoindexMeta},
+		{200, http.Header{"Content-Type": {"text/html"}}, `<link rel="canonical" href="/other">`, ReasonCanonicalizedHTML},
+	}
+	for _, test := range tests {
+		resp := &http.Response{
+			StatusCode: test.status,
+			Header:     test.header,
+			Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+		}
+		r := MakeResult("https://example.com/page", 0, resp)
+		if r.IndexabilityReason != test.reason || r.Indexable != (test.reason == "") {
+			t.Errorf("%d %v %q: expected reason %q, got %q",
+				test.status, test.header, test.body, test.reason, r.IndexabilityReason)
+		}
+	}
+}
@@ -1,11 +1,18 @@
 package crawler
 
 import (
+	"compress/gzip"
 	"fmt"
 	"html/template"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/benjaminestes/crawl/crawler/data"
 )
 
 func TestDisallowServer(t *testing.T) {
@@ -114,3 +121,294 @@ func TestAllowServer(t *testing.T) {
 		t.Errorf("expected %d URLs, returned %d", wantCount, count)
 	}
 }
+
+func TestFetchResources(t *testing.T) {
+	var heads int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "user-agent: *\ndisallow: /blocked.png\n")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<img src="/a.png"><img src="/blocked.png"><script src="/b.js"></script><a href="/c">C</a>`)
+	})
+	mux.HandleFunc("/a.png", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "HEAD" {
+			heads++
+		}
+		w.Header().Set("Content-Type", "image/png")
+	})
+	mux.HandleFunc("/b.js", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "HEAD" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprintf(w, "script()")
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := &Crawler{
+		From:            []string{ts.URL},
+		MaxDepth:        0,
+		RobotsUserAgent: "Crawler",
+		Connections:     1,
+		FetchResources:  true,
+		WaitTime:        "1ms",
+		Timeout:         "30s",
+	}
+
+	err := c.Start()
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+
+	var pages, resources int
+	for n := c.Next(); n != nil; n = c.Next() {
+		if !n.Resource {
+			pages++
+			continue
+		}
+		resources++
+		if n.Depth != 0 {
+			t.Errorf("resource %s should have the depth of its page, got %d", n.Address.Full, n.Depth)
+		}
+		if n.Links != nil {
+			t.Errorf("resource %s shouldn't have links", n.Address.Full)
+		}
+		if n.Address.Path == "/b.js" && n.ContentLength != int64(len("script()")) {
+			t.Errorf("expected GET fallback for %s, got length %d", n.Address.Full, n.ContentLength)
+		}
+	}
+
+	if pages != 1 || resources != 3 {
+		t.Errorf("expected 1 page and 3 resources, got %d and %d", pages, resources)
+	}
+	if heads != 1 {
+		t.Errorf("expected 1 HEAD request, got %d", heads)
+	}
+}
+
+func TestFollowMetaRefresh(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "user-agent: *\nallow: /\n")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if req.URL.Path == "/" {
+			fmt.Fprintf(w, `<meta http-equiv="refresh" content="0; url=/new">`)
+		}
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	for _, follow := range []bool{false, true} {
+		c := &Crawler{
+			From:              []string{ts.URL},
+			MaxDepth:          1,
+			RobotsUserAgent:   "Crawler",
+			Connections:       1,
+			FollowMetaRefresh: follow,
+			WaitTime:          "1ms",
+			Timeout:           "30s",
+		}
+
+		err := c.Start()
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+
+		var count int
+		for n := c.Next(); n != nil; n = c.Next() {
+			count++
+		}
+
+		wantCount := 1
+		if follow {
+			wantCount = 2
+		}
+		if count != wantCount {
+			t.Errorf("FollowMetaRefresh=%v: expected %d URLs, returned %d", follow, wantCount, count)
+		}
+	}
+}
+
+func TestWARC(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "user-agent: *\nallow: /\n")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Accept-Encoding") != "" {
+			t.Errorf("archived responses should not be compressed")
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<title>Archived</title><a href="/a">A</a>`)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "crawler")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &Crawler{
+		From:            []string{ts.URL},
+		MaxDepth:        1,
+		RobotsUserAgent: "Crawler",
+		Connections:     1,
+		WARC:            filepath.Join(dir, "crawl.warc.gz"),
+		WaitTime:        "1ms",
+		Timeout:         "30s",
+	}
+	if err := c.Start(); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	var results []*data.Result
+	for n := c.Next(); n != nil; n = c.Next() {
+		results = append(results, n)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	for _, r := range results {
+		if r.WARCFilename != "crawl.warc.gz" || r.WARCOffset == 0 {
+			t.Errorf("%s: unexpected WARC reference %s@%d", r.Address.Full, r.WARCFilename, r.WARCOffset)
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, r.WARCFilename))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		f.Seek(r.WARCOffset, 0)
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		gz.Multistream(false)
+		record, _ := ioutil.ReadAll(gz)
+		f.Close()
+		if !strings.Contains(string(record), "WARC-Type: response\r\n") ||
+			!strings.Contains(string(record), "WARC-Target-URI: "+r.Address.Full+"\r\n") ||
+			!strings.Contains(string(record), "<title>Archived</title>") {
+			t.Errorf("%s: unexpected record %q", r.Address.Full, record)
+		}
+		if r.Title != "Archived" {
+			t.Errorf("%s: body wasn't hydrated after being archived", r.Address.Full)
+		}
+	}
+}
+
+func TestWARCTruncated(t *testing.T) {
+	defer func(n int64) { maxArchivedBody = n }(maxArchivedBody)
+	maxArchivedBody = 16
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "user-agent: *\nallow: /\n")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<title>Archived</title><a href="/a">A</a>`)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "crawler")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &Crawler{
+		From:            []string{ts.URL},
+		RobotsUserAgent: "Crawler",
+		Connections:     1,
+		WARC:            filepath.Join(dir, "crawl.warc.gz"),
+		WaitTime:        "1ms",
+		Timeout:         "30s",
+	}
+	if err := c.Start(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	r := c.Next()
+	for n := r; n != nil; n = c.Next() {
+	}
+	if err := c.Err(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if r.Title != "Archived" || len(r.Links) != 1 {
+		t.Errorf("truncated body wasn't hydrated completely: %+v", r)
+	}
+
+	f, err := os.Open(filepath.Join(dir, r.WARCFilename))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer f.Close()
+	f.Seek(r.WARCOffset, 0)
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	gz.Multistream(false)
+	record, _ := ioutil.ReadAll(gz)
+	if !strings.Contains(string(record), "WARC-Truncated: length\r\n") ||
+		!strings.Contains(string(record), "\r\n\r\n<title>Archived<\r\n\r\n") {
+		t.Errorf("unexpected record %q", record)
+	}
+}
+
+func TestWARCError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "user-agent: *\nallow: /\n")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, `<a href="/a">A</a>`)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "crawler")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &Crawler{
+		From:            []string{ts.URL},
+		MaxDepth:        1,
+		RobotsUserAgent: "Crawler",
+		Connections:     1,
+		WARC:            filepath.Join(dir, "crawl.warc.gz"),
+		WaitTime:        "1ms",
+		Timeout:         "30s",
+	}
+	if err := c.Start(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	// Closing the file makes every write to it fail.
+	c.warc.Close()
+
+	count := 0
+	for n := c.Next(); n != nil; n = c.Next() {
+		count++
+	}
+	if c.Err() == nil {
+		t.Errorf("failing to archive should stop the crawl with an error")
+	}
+	if count != 1 {
+		t.Errorf("expected the crawl to stop after 1 result, got %d", count)
+	}
+}
@@ -27,6 +27,9 @@ func crawlStartQueue(c *Crawler) crawlfn {
 // crawlStart is the beginning of the process of crawling a single
 // URL.
 func crawlStart(c *Crawler) crawlfn {
+	if c.Err() != nil {
+		return crawlStop
+	}
 	if time.Since(c.lastRequestTime) < c.wait {
 		return crawlWait
 	}
@@ -56,7 +59,11 @@ func crawlCheckRobots(c *Crawler) crawlfn {
 	if !c.robots[rtxtURL](addr.String()) {
 		// FIXME: Can this be some sort of "emit error" func?
 		result := data.MakeResult(addr.String(), c.depth, nil)
+		if depth, ok := c.resourceDepth(addr); ok {
+			result = data.MakeResourceResult(addr.String(), depth, nil)
+		}
 		result.Status = "Blocked by robots.txt"
+		result.IndexabilityReason = data.ReasonBlockedByRobotsTxt
 		c.results <- result
 		return crawlNext
 	}
@@ -104,6 +111,13 @@ func crawlAwait(c *Crawler) crawlfn {
 	return crawlNextQueue
 }
 
+// crawlStop waits for all currently active fetches to finish, and
+// ends the crawl early because of an error.
+func crawlStop(c *Crawler) crawlfn {
+	c.wg.Wait()
+	return nil
+}
+
 // crawlNextQueue replace the current queue with the next and starts
 // the process again. This next queue represents the accumulated URLs
 // in the next level of the crawl that we haven't yet seen.
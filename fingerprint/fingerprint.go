@@ -0,0 +1,64 @@
+// Package fingerprint is an internal package of the tool Crawl,
+// responsible for computing locality-sensitive fingerprints of page
+// text, so that near-duplicate pages can be found.
+package fingerprint
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// shingleSize is the number of consecutive words in each feature of
+// a SimHash.
+const shingleSize = 3
+
+// SimHash returns a 64-bit SimHash of text. Texts that differ only
+// slightly have SimHashes that differ in only a few bits, as measured
+// by Distance. The features of the hash are overlapping runs of
+// words, ignoring case and punctuation. SimHash returns 0 for text
+// without words.
+func SimHash(text string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+
+	var v [64]int
+	add := func(feature string) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for i := range v {
+			if sum&(1<<uint(i)) != 0 {
+				v[i]++
+			} else {
+				v[i]--
+			}
+		}
+	}
+
+	if len(words) < shingleSize {
+		add(strings.Join(words, " "))
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		add(strings.Join(words[i:i+shingleSize], " "))
+	}
+
+	var hash uint64
+	for i := range v {
+		if v[i] > 0 {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// Distance returns the Hamming distance between two SimHashes: the
+// number of bits in which they differ.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,33 @@
+package fingerprint
+
+import (
+	"strings"
+	"testing"
+)
+
+const article = `The quick brown fox jumps over the lazy dog. It was a bright
+cold day in April, and the clocks were striking thirteen. Call me
+Ishmael. Some years ago, never mind how long precisely, having little
+or no money in my purse, and nothing particular to interest me on
+shore, I thought I would sail about a little and see the watery part
+of the world. It is a truth universally acknowledged, that a single
+man in possession of a good fortune, must be in want of a wife.`
+
+func TestSimHash(t *testing.T) {
+	a := SimHash(article)
+	b := SimHash(strings.Replace(article, "April", "May", 1))
+	c := SimHash("Completely unrelated text about databases, indexes, and query planners.")
+
+	if SimHash(strings.ToUpper(article)) != a {
+		t.Errorf("SimHash should ignore case")
+	}
+	if d := Distance(a, b); d > 8 {
+		t.Errorf("expected similar texts to be close, got distance %d", d)
+	}
+	if d := Distance(a, c); d < 16 {
+		t.Errorf("expected different texts to be far apart, got distance %d", d)
+	}
+	if SimHash(" ... ") != 0 {
+		t.Errorf("expected 0 for text without words")
+	}
+}
@@ -1,8 +1,30 @@
 module github.com/benjaminestes/crawl
 
-go 1.13
+go 1.17
 
 require (
+	github.com/abadojack/whatlanggo v1.0.1
 	github.com/benjaminestes/robots/v2 v2.0.5
-	golang.org/x/net v0.0.0-20191116160921-f9c825593386
+	github.com/klauspost/compress v1.13.1
+	github.com/lib/pq v1.10.9
+	github.com/xitongsys/parquet-go v1.6.2
+	github.com/xitongsys/parquet-go-source v0.0.0-20200817004010-026bad9b25d0
+	golang.org/x/net v0.0.0-20201021035429-f5854403a974
+	golang.org/x/text v0.3.3
+	modernc.org/sqlite v1.17.3
+)
+
+require (
+	github.com/apache/arrow/go/arrow v0.0.0-20200730104253-651201b0f516 // indirect
+	github.com/apache/thrift v0.14.2 // indirect
+	github.com/golang/snappy v0.0.3 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+	github.com/mattn/go-isatty v0.0.12 // indirect
+	github.com/pierrec/lz4/v4 v4.1.8 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20200410134404-eec4a21b6bb0 // indirect
+	golang.org/x/sys v0.0.0-20211007075335-d3039528d8ac // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	modernc.org/libc v1.16.7 // indirect
+	modernc.org/mathutil v1.4.1 // indirect
+	modernc.org/memory v1.1.1 // indirect
 )
@@ -0,0 +1,249 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+	"github.com/benjaminestes/crawl/schema"
+)
+
+// CSV is a Sink that flattens results into tables for use in
+// spreadsheets. Each result becomes one row of the pages table, whose
+// columns are the scalar fields of data.Result; nested records are
+// flattened into dotted column names, like "Address.Full". Each
+// repeated record of data.Result, like Links, Hreflang and Header,
+// gets a companion table with one row per element, keyed by the Page
+// column holding the URL of the result it came from. Repeated scalars,
+// like the rel tokens of a link, are joined with spaces.
+//
+// The tables are written to files named after a common prefix: with
+// prefix "crawl", pages go to crawl.csv, links to crawl_links.csv,
+// and so on.
+type CSV struct {
+	pages  *csvTable
+	tables []*csvTable
+	files  []*os.File
+}
+
+// A csvTable is one file written by a CSV sink. If field is nil, the
+// table holds one row per result; otherwise it holds one row per
+// element of the repeated field at index field.Index. Columns are
+// named by their Path.
+type csvTable struct {
+	w       *csv.Writer
+	field   *schema.Field
+	columns []schema.Column
+}
+
+// NewCSV returns a CSV sink writing files that begin with prefix and
+// end with ext, separating values with comma. If columns is not
+// empty, the pages table includes only the named columns, in the
+// order given.
+func NewCSV(prefix, ext string, comma rune, columns []string) (*CSV, error) {
+	s := &CSV{}
+	fields := schema.Fields(reflect.TypeOf(data.Result{}))
+
+	pageColumns := schema.FlatColumns(fields)
+	if len(columns) > 0 {
+		var err error
+		if pageColumns, err = selectColumns(pageColumns, columns); err != nil {
+			return nil, err
+		}
+	}
+	pages, err := s.create(prefix+ext, comma, nil, pageColumns)
+	if err != nil {
+		s.closeFiles()
+		return nil, err
+	}
+	s.pages = pages
+
+	for i := range fields {
+		f := &fields[i]
+		if !f.Repeated || !f.IsRecord() {
+			continue
+		}
+		name := fmt.Sprintf("%s_%s%s", prefix, strings.ToLower(f.Name), ext)
+		t, err := s.create(name, comma, f, schema.FlatColumns(f.Fields))
+		if err != nil {
+			s.closeFiles()
+			return nil, err
+		}
+		s.tables = append(s.tables, t)
+	}
+	return s, nil
+}
+
+func openCSV(dest string, opts url.Values) (Sink, error) {
+	return openDelimited("csv", dest, opts, ',')
+}
+
+func openTSV(dest string, opts url.Values) (Sink, error) {
+	return openDelimited("tsv", dest, opts, '\t')
+}
+
+// openDelimited opens a CSV sink. The columns option, if present, is
+// a comma-separated list of the columns of the pages table.
+func openDelimited(format, dest string, opts url.Values, comma rune) (Sink, error) {
+	if err := checkOptions(format, opts, "columns"); err != nil {
+		return nil, err
+	}
+	if dest == "" {
+		return nil, fmt.Errorf("%s output writes several files and needs a destination prefix, as in %s:crawl", format, format)
+	}
+	var columns []string
+	if c := opts.Get("columns"); c != "" {
+		columns = strings.Split(c, ",")
+	}
+	ext := "." + format
+	return NewCSV(strings.TrimSuffix(dest, ext), ext, comma, columns)
+}
+
+// create opens the file for a table and writes its header row.
+func (s *CSV) create(name string, comma rune, field *schema.Field, columns []schema.Column) (*csvTable, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	s.files = append(s.files, f)
+	w := csv.NewWriter(f)
+	w.Comma = comma
+
+	var header []string
+	if field != nil {
+		header = append(header, "Page")
+	}
+	for _, c := range columns {
+		header = append(header, c.Path)
+	}
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvTable{w: w, field: field, columns: columns}, nil
+}
+
+func selectColumns(available []schema.Column, names []string) ([]schema.Column, error) {
+	byName := make(map[string]schema.Column)
+	for _, c := range available {
+		byName[c.Path] = c
+	}
+	var columns []schema.Column
+	for _, name := range names {
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown csv column %q", name)
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+func (s *CSV) Write(r *data.Result) error {
+	v := reflect.ValueOf(r).Elem()
+	if err := s.pages.w.Write(s.pages.row(v)); err != nil {
+		return err
+	}
+
+	var page string
+	if r.Address != nil {
+		page = r.Address.Full
+	}
+	for _, t := range s.tables {
+		elems := v.Field(t.field.Index)
+		for i := 0; i < elems.Len(); i++ {
+			row := append([]string{page}, t.row(elems.Index(i))...)
+			if err := t.w.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// row formats the values of the table's columns in v, which is a
+// struct or a pointer to one.
+func (t *csvTable) row(v reflect.Value) []string {
+	row := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		row[i] = formatValue(fieldByIndex(v, c.Index))
+	}
+	return row
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but follows
+// pointers, returning an invalid Value if any of them is nil.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return ""
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Slice:
+		var parts []string
+		for i := 0; i < v.Len(); i++ {
+			parts = append(parts, formatValue(v.Index(i)))
+		}
+		return strings.Join(parts, " ")
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ""
+		}
+		return formatValue(v.Elem())
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func (s *CSV) Flush() error {
+	for _, t := range append([]*csvTable{s.pages}, s.tables...) {
+		t.w.Flush()
+		if err := t.w.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CSV) Close() error {
+	err := s.Flush()
+	if cerr := s.closeFiles(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (s *CSV) closeFiles() error {
+	var err error
+	for _, f := range s.files {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
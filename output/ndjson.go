@@ -0,0 +1,103 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// NDJSON is a Sink that writes each result as a line of JSON. This is
+// the format expected by BigQuery, and read by the analysis commands
+// of crawl.
+type NDJSON struct {
+	w   io.WriteCloser
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewNDJSON returns an NDJSON sink writing to w. Closing the sink
+// closes w.
+func NewNDJSON(w io.WriteCloser) *NDJSON {
+	buf := bufio.NewWriter(w)
+	return &NDJSON{
+		w:   w,
+		buf: buf,
+		enc: json.NewEncoder(buf),
+	}
+}
+
+// openNDJSON opens an NDJSON sink. The compress option selects gzip
+// or zstd compression. The records and size options split the output
+// into numbered files of at most that many records or bytes, as
+// described by rotator.
+func openNDJSON(dest string, opts url.Values) (Sink, error) {
+	if err := checkOptions("ndjson", opts, "compress", "records", "size"); err != nil {
+		return nil, err
+	}
+	var maxRecords, maxBytes int64
+	if s := opts.Get("records"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("bad number of records %q", s)
+		}
+		maxRecords = n
+	}
+	if s := opts.Get("size"); s != "" {
+		n, err := parseSize(s)
+		if err != nil {
+			return nil, err
+		}
+		maxBytes = n
+	}
+	w, err := newRotator(dest, opts.Get("compress"), maxRecords, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return NewNDJSON(w), nil
+}
+
+// A recordWriter is a writer that needs to know where records end,
+// like a rotator.
+type recordWriter interface {
+	io.WriteCloser
+	EndRecord() error
+	Flush() error
+}
+
+func (s *NDJSON) Write(r *data.Result) error {
+	// Encode terminates each value with a newline.
+	if err := s.enc.Encode(r); err != nil {
+		return err
+	}
+	rw, ok := s.w.(recordWriter)
+	if !ok {
+		return nil
+	}
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+	return rw.EndRecord()
+}
+
+func (s *NDJSON) Flush() error {
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+	if rw, ok := s.w.(recordWriter); ok {
+		return rw.Flush()
+	}
+	return nil
+}
+
+func (s *NDJSON) Close() error {
+	if err := s.Flush(); err != nil {
+		s.w.Close()
+		return err
+	}
+	return s.w.Close()
+}
@@ -0,0 +1,116 @@
+// Package output is an internal package of the tool Crawl,
+// responsible for writing the results of a crawl to their
+// destination.
+package output
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// A Sink receives the results of a crawl and writes them
+// somewhere. Write may buffer results; Flush forces any buffered
+// results to be written. Close flushes the sink and releases its
+// resources. A Sink is not safe for concurrent use.
+type Sink interface {
+	Write(*data.Result) error
+	Flush() error
+	Close() error
+}
+
+// DefaultFormat is the format used when a spec doesn't name one.
+const DefaultFormat = "ndjson"
+
+// openers maps the name of each output format to a function that
+// opens a Sink of that format writing to dest. The meaning of dest
+// depends on the format; for most formats it is a file name, and an
+// empty dest means stdout. Openers should reject options they don't
+// understand using checkOptions.
+var openers = map[string]func(dest string, opts url.Values) (Sink, error){
+	"csv":      openCSV,
+	"ndjson":   openNDJSON,
+	"parquet":  openParquet,
+	"postgres": openPostgres,
+	"sqlite":   openSQLite,
+	"tsv":      openTSV,
+}
+
+// Formats returns the names of the available output formats.
+func Formats() []string {
+	var formats []string
+	for f := range openers {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Open returns a Sink described by spec, which has the form
+// "format[:destination][?options]", as in "ndjson:crawl.jsonl" or
+// "csv:crawl?columns=Address.Full,StatusCode". Options are encoded
+// like a URL query string; which are accepted depends on the format.
+// If spec is empty, results are written to stdout in DefaultFormat.
+func Open(spec string) (Sink, error) {
+	var opts url.Values
+	if i := strings.Index(spec, "?"); i >= 0 {
+		var err error
+		if opts, err = url.ParseQuery(spec[i+1:]); err != nil {
+			return nil, fmt.Errorf("bad output options: %v", err)
+		}
+		spec = spec[:i]
+	}
+	format, dest := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		format, dest = spec[:i], spec[i+1:]
+	}
+	if format == "" {
+		format = DefaultFormat
+	}
+	open, ok := openers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q (expected one of %s)",
+			format, strings.Join(Formats(), ", "))
+	}
+	return open(dest, opts)
+}
+
+// checkOptions returns an error if opts contains an option that is
+// not one of known.
+func checkOptions(format string, opts url.Values, known ...string) error {
+	for k := range opts {
+		ok := false
+		for _, name := range known {
+			if k == name {
+				ok = true
+			}
+		}
+		if !ok {
+			return fmt.Errorf("unknown option %q for %s output", k, format)
+		}
+	}
+	return nil
+}
+
+// createOrStdout opens the file named dest for writing, or returns
+// stdout if dest is empty. Closing the returned stdout does nothing,
+// so that other output, like the crawl summary, is unaffected.
+func createOrStdout(dest string) (io.WriteCloser, error) {
+	if dest == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(dest)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
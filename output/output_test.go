@@ -0,0 +1,172 @@
+package output
+
+import (
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+func TestOpenUnknownFormat(t *testing.T) {
+	if _, err := Open("nonsense:out.txt"); err == nil {
+		t.Errorf("unknown format should trigger error")
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "crawl.jsonl")
+	s, err := Open("ndjson:" + path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for _, u := range []string{"https://example.com/", "https://example.com/a"} {
+		if err := s.Write(&data.Result{Address: data.MakeAddress(u)}); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], `{"Address":{"Full":"https://example.com/a"`) {
+		t.Errorf("unexpected output: %s", b)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	prefix := filepath.Join(dir, "crawl")
+	s, err := Open("csv:" + prefix + ".csv")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	page := "https://example.com/"
+	r := &data.Result{
+		Address:    data.MakeAddress(page),
+		Title:      "Example, with a comma",
+		StatusCode: 200,
+		Links: []*data.Link{
+			data.MakeLink(data.MakeAddress(page), "/a", "A", "nofollow noopener"),
+		},
+		Header: []*data.Pair{{K: "Content-Type", V: "text/html"}},
+	}
+	if err := s.Write(r); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	read := func(name string) [][]string {
+		f, err := os.Open(prefix + name)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		defer f.Close()
+		rows, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		return rows
+	}
+	cell := func(rows [][]string, col string) string {
+		for i, name := range rows[0] {
+			if name == col {
+				return rows[1][i]
+			}
+		}
+		t.Fatalf("missing column %s in %v", col, rows[0])
+		return ""
+	}
+
+	pages := read(".csv")
+	if len(pages) != 2 {
+		t.Fatalf("want header and one row, got %v", pages)
+	}
+	if got := cell(pages, "Address.Full"); got != page {
+		t.Errorf("Address.Full = %q", got)
+	}
+	if got := cell(pages, "Title"); got != r.Title {
+		t.Errorf("Title = %q", got)
+	}
+	if got := cell(pages, "StatusCode"); got != "200" {
+		t.Errorf("StatusCode = %q", got)
+	}
+
+	links := read("_links.csv")
+	if len(links) != 2 {
+		t.Fatalf("want header and one link, got %v", links)
+	}
+	if got := cell(links, "Page"); got != page {
+		t.Errorf("Page = %q", got)
+	}
+	if got := cell(links, "Address.Full"); got != "https://example.com/a" {
+		t.Errorf("link Address.Full = %q", got)
+	}
+	if got := cell(links, "Rel"); got != "nofollow noopener" {
+		t.Errorf("Rel = %q", got)
+	}
+
+	headers := read("_header.csv")
+	if got := cell(headers, "K"); got != "Content-Type" {
+		t.Errorf("Key = %q", got)
+	}
+}
+
+func TestCSVColumns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	prefix := filepath.Join(dir, "crawl")
+	if _, err := Open("csv:" + prefix + "?columns=Nonsense"); err == nil {
+		t.Errorf("unknown column should trigger error")
+	}
+	if _, err := Open("csv:" + prefix + "?nonsense=1"); err == nil {
+		t.Errorf("unknown option should trigger error")
+	}
+	s, err := Open("csv:" + prefix + "?columns=StatusCode,Address.Full")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	s.Write(&data.Result{Address: data.MakeAddress("https://example.com/"), StatusCode: 404})
+	if err := s.Close(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	b, err := ioutil.ReadFile(prefix + ".csv")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	want := "StatusCode,Address.Full\n404,https://example.com/\n"
+	if string(b) != want {
+		t.Errorf("got %q, want %q", b, want)
+	}
+}
+
+func TestCSVNeedsDestination(t *testing.T) {
+	if _, err := Open("csv"); err == nil {
+		t.Errorf("csv without destination should trigger error")
+	}
+}
@@ -0,0 +1,178 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+	"github.com/benjaminestes/crawl/schema"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// ParquetOptions configure a Parquet sink.
+type ParquetOptions struct {
+	// RowGroupSize is the approximate size in bytes of a row
+	// group. If it is zero, row groups are 128MB.
+	RowGroupSize int64
+
+	// Compression is the codec used to compress column chunks:
+	// one of uncompressed, snappy, gzip, lz4, or zstd. If it is
+	// empty, snappy is used.
+	Compression string
+}
+
+// Parquet is a Sink that writes results to an Apache Parquet file.
+// The schema of the file mirrors data.Result: nested records become
+// groups, and repeated records, like Links and Hreflang, become
+// repeated groups, so the file loads into BigQuery or DuckDB with the
+// same structure as the NDJSON output.
+//
+// Row groups are written as they fill, and the file is not readable
+// until the sink is closed, so Flush does nothing.
+type Parquet struct {
+	w  io.WriteCloser
+	pw *writer.JSONWriter
+}
+
+// NewParquet returns a Parquet sink writing to w. Closing the sink
+// closes w.
+func NewParquet(w io.WriteCloser, opts ParquetOptions) (*Parquet, error) {
+	pw, err := writer.NewJSONWriterFromWriter(parquetSchema(), w, 4)
+	if err != nil {
+		return nil, err
+	}
+	if opts.RowGroupSize > 0 {
+		pw.RowGroupSize = opts.RowGroupSize
+	}
+	if opts.Compression != "" {
+		codec, err := parquetCodec(opts.Compression)
+		if err != nil {
+			return nil, err
+		}
+		pw.CompressionType = codec
+	}
+	return &Parquet{w: w, pw: pw}, nil
+}
+
+func parquetCodec(name string) (parquet.CompressionCodec, error) {
+	codec, err := parquet.CompressionCodecFromString(strings.ToUpper(name))
+	if err != nil {
+		return codec, fmt.Errorf("unknown parquet compression %q", name)
+	}
+	return codec, nil
+}
+
+// openParquet opens a Parquet sink. It accepts the options
+// compression, naming a codec, and rowgroup, giving the row group
+// size in bytes.
+func openParquet(dest string, opts url.Values) (Sink, error) {
+	if err := checkOptions("parquet", opts, "compression", "rowgroup"); err != nil {
+		return nil, err
+	}
+	var po ParquetOptions
+	if po.Compression = opts.Get("compression"); po.Compression != "" {
+		// Check before creating the file, so that a mistake
+		// doesn't leave an empty one behind.
+		if _, err := parquetCodec(po.Compression); err != nil {
+			return nil, err
+		}
+	}
+	if s := opts.Get("rowgroup"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("bad parquet row group size %q", s)
+		}
+		po.RowGroupSize = n
+	}
+	w, err := createOrStdout(dest)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewParquet(w, po)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Parquet) Write(r *data.Result) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return s.pw.Write(string(b))
+}
+
+func (s *Parquet) Flush() error {
+	return nil
+}
+
+func (s *Parquet) Close() error {
+	if err := s.pw.WriteStop(); err != nil {
+		s.w.Close()
+		return err
+	}
+	return s.w.Close()
+}
+
+// A parquetField is the JSON description of a column or group
+// understood by the parquet writer.
+type parquetField struct {
+	Tag    string
+	Fields []*parquetField `json:",omitempty"`
+}
+
+// parquetSchema returns the schema of data.Result in the JSON form
+// understood by the parquet writer.
+func parquetSchema() string {
+	root := &parquetField{
+		Tag:    "name=Result, repetitiontype=REQUIRED",
+		Fields: parquetFields(schema.Fields(reflect.TypeOf(data.Result{}))),
+	}
+	b, err := json.Marshal(root)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+func parquetFields(fields []schema.Field) []*parquetField {
+	var pfs []*parquetField
+	for _, f := range fields {
+		tag := "name=" + f.Name
+		if !f.IsRecord() {
+			tag += ", " + parquetType(f.Type)
+		}
+		if f.Repeated {
+			tag += ", repetitiontype=REPEATED"
+		} else {
+			tag += ", repetitiontype=OPTIONAL"
+		}
+		pf := &parquetField{Tag: tag}
+		if f.IsRecord() {
+			pf.Fields = parquetFields(f.Fields)
+		}
+		pfs = append(pfs, pf)
+	}
+	return pfs
+}
+
+func parquetType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Float64:
+		return "type=DOUBLE"
+	case reflect.Int, reflect.Int64:
+		return "type=INT64"
+	case reflect.Bool:
+		return "type=BOOLEAN"
+	default:
+		return "type=BYTE_ARRAY, convertedtype=UTF8"
+	}
+}
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestParquet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "crawl.parquet")
+	s, err := Open("parquet:" + path + "?compression=gzip&rowgroup=1048576")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	page := data.MakeAddress("https://example.com/")
+	for _, u := range []string{"/a", "/b"} {
+		r := &data.Result{
+			Address:    data.MakeAddress("https://example.com" + u),
+			StatusCode: 200,
+			Links: []*data.Link{
+				data.MakeLink(page, "/c", "C", "nofollow noopener"),
+			},
+		}
+		if err := s.Write(r); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	f, err := buffer.NewBufferFile(b)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	pr, err := reader.NewParquetReader(f, nil, 1)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer pr.ReadStop()
+	if n := pr.GetNumRows(); n != 2 {
+		t.Fatalf("want 2 rows, got %d", n)
+	}
+	rows, err := pr.ReadByNumber(2)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	got, err := json.Marshal(rows[1])
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for _, want := range []string{
+		`"Full":"https://example.com/b"`,
+		`"StatusCode":200`,
+		`"Full":"https://example.com/c"`,
+		`"Rel":["nofollow","noopener"]`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("row %s doesn't contain %s", got, want)
+		}
+	}
+}
+
+func TestParquetOptions(t *testing.T) {
+	for _, spec := range []string{
+		"parquet:x.parquet?compression=nonsense",
+		"parquet:x.parquet?rowgroup=big",
+		"parquet:x.parquet?nonsense=1",
+	} {
+		if s, err := Open(spec); err == nil {
+			s.Close()
+			t.Errorf("%s should trigger error", spec)
+		}
+	}
+	if _, err := os.Stat("x.parquet"); err == nil {
+		os.Remove("x.parquet")
+		t.Errorf("bad options should not create a file")
+	}
+}
@@ -0,0 +1,212 @@
+package output
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+	"github.com/benjaminestes/crawl/schema"
+	"github.com/lib/pq"
+)
+
+// PostgresOptions configure a Postgres sink.
+type PostgresOptions struct {
+	// Table is the name of the table of results, and the prefix
+	// of the names of other tables. If it is empty, "crawl" is
+	// used.
+	Table string
+
+	// Normalized selects the layout of the tables, as described
+	// by schema.Tables: if it is true, repeated records are
+	// stored in tables of their own; otherwise, they are stored
+	// as JSONB.
+	Normalized bool
+
+	// BatchSize is the number of results sent to the database at
+	// once. If it is zero, results are sent in batches of 1000.
+	BatchSize int
+}
+
+// Postgres is a Sink that writes results to PostgreSQL. The tables
+// are created if they don't exist, and results are appended to them.
+// Results are buffered and streamed to the database in batches using
+// COPY, each in a transaction of its own.
+type Postgres struct {
+	db        *sql.DB
+	tables    []schema.Table
+	batch     []*data.Result
+	batchSize int
+}
+
+// postgresDriver is the name of the database/sql driver used by
+// Postgres sinks. Tests replace it with a driver that records what
+// would be sent to the server.
+var postgresDriver = "postgres"
+
+// NewPostgres returns a Postgres sink writing to the database
+// described by the connection string conn, as understood by
+// github.com/lib/pq.
+func NewPostgres(conn string, opts PostgresOptions) (*Postgres, error) {
+	if opts.Table == "" {
+		opts.Table = "crawl"
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	db, err := sql.Open(postgresDriver, conn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema.PostgresDDL(opts.Table, opts.Normalized)); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Postgres{
+		db:        db,
+		tables:    schema.Tables(opts.Table, opts.Normalized),
+		batchSize: opts.BatchSize,
+	}, nil
+}
+
+// openPostgres opens a Postgres sink. dest is a connection URL, as in
+// postgres://localhost/crawl. The options table and batch set the
+// corresponding PostgresOptions, and mode is either jsonb (the
+// default) or normalized. Other options are passed on to the database
+// as connection parameters, like sslmode.
+func openPostgres(dest string, opts url.Values) (Sink, error) {
+	if dest == "" {
+		return nil, errors.New("postgres output needs a connection URL, as in postgres:postgres://localhost/crawl")
+	}
+	var po PostgresOptions
+	po.Table = opts.Get("table")
+	switch mode := opts.Get("mode"); mode {
+	case "", "jsonb":
+	case "normalized":
+		po.Normalized = true
+	default:
+		return nil, fmt.Errorf("unknown postgres mode %q (expected jsonb or normalized)", mode)
+	}
+	if s := opts.Get("batch"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("bad postgres batch size %q", s)
+		}
+		po.BatchSize = n
+	}
+
+	params := url.Values{}
+	for k, v := range opts {
+		if k != "table" && k != "mode" && k != "batch" {
+			params[k] = v
+		}
+	}
+	if len(params) > 0 {
+		u, err := url.Parse(dest)
+		if err != nil || u.Scheme == "" {
+			return nil, fmt.Errorf("connection parameters need a postgres:// URL")
+		}
+		q := u.Query()
+		for k, v := range params {
+			q[k] = v
+		}
+		u.RawQuery = q.Encode()
+		dest = u.String()
+	}
+	return NewPostgres(dest, po)
+}
+
+func (s *Postgres) Write(r *data.Result) error {
+	s.batch = append(s.batch, r)
+	if len(s.batch) < s.batchSize {
+		return nil
+	}
+	return s.Flush()
+}
+
+// Flush sends the buffered results to the database.
+func (s *Postgres) Flush() error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, t := range s.tables {
+		if err := s.copyTable(tx, t); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("copying to %s: %v", t.Name, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.batch = s.batch[:0]
+	return nil
+}
+
+// copyTable sends the rows of table t for the buffered results.
+func (s *Postgres) copyTable(tx *sql.Tx, t schema.Table) error {
+	var names []string
+	for _, c := range t.Columns {
+		names = append(names, c.Name)
+	}
+	stmt, err := tx.Prepare(pq.CopyIn(t.Name, names...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range s.batch {
+		v := reflect.ValueOf(r).Elem()
+		if t.Field == nil {
+			if _, err := stmt.Exec(sqlRow(v, t.Columns, postgresValue)...); err != nil {
+				return err
+			}
+			continue
+		}
+		// The first column of the table is the page, which
+		// isn't a field of the elements.
+		page := fullAddress(r.Address)
+		elems := v.Field(t.Field.Index)
+		for i := 0; i < elems.Len(); i++ {
+			row := append([]interface{}{page}, sqlRow(elems.Index(i), t.Columns[1:], postgresValue)...)
+			if _, err := stmt.Exec(row...); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = stmt.Exec()
+	return err
+}
+
+// postgresValue returns the value of f in column c for PostgreSQL:
+// records are JSON, and repeated scalars are arrays.
+func postgresValue(c schema.Column, f reflect.Value) interface{} {
+	switch {
+	case c.Nested:
+		b, err := json.Marshal(f.Interface())
+		if err != nil {
+			// Results are always encodable as JSON.
+			panic(err)
+		}
+		return string(b)
+	case c.Repeated:
+		return pq.Array(f.Interface())
+	default:
+		return f.Interface()
+	}
+}
+
+func (s *Postgres) Close() error {
+	if err := s.Flush(); err != nil {
+		s.db.Close()
+		return err
+	}
+	return s.db.Close()
+}
@@ -0,0 +1,237 @@
+package output
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+	"github.com/benjaminestes/crawl/schema"
+)
+
+func TestPostgresOptions(t *testing.T) {
+	for _, spec := range []string{
+		"postgres",
+		"postgres:postgres://localhost/crawl?mode=nonsense",
+		"postgres:postgres://localhost/crawl?batch=0",
+		"postgres:host=localhost?sslmode=disable",
+	} {
+		if s, err := Open(spec); err == nil {
+			s.Close()
+			t.Errorf("%s should trigger error", spec)
+		}
+	}
+}
+
+// copyRecorder is a database/sql driver standing in for a
+// PostgreSQL server. It records the statements executed outside of
+// COPY, and the rows sent by COPY to each table.
+type copyRecorder struct {
+	stmts []string
+	rows  map[string][][]driver.Value
+}
+
+var recorder = &copyRecorder{}
+
+func init() {
+	sql.Register("postgres-recorder", recorder)
+}
+
+func (d *copyRecorder) Open(name string) (driver.Conn, error) {
+	return recorderConn{d}, nil
+}
+
+type recorderConn struct{ d *copyRecorder }
+
+func (c recorderConn) Prepare(query string) (driver.Stmt, error) {
+	return recorderStmt{c.d, query}, nil
+}
+
+func (c recorderConn) Close() error              { return nil }
+func (c recorderConn) Begin() (driver.Tx, error) { return recorderTx{}, nil }
+
+type recorderTx struct{}
+
+func (recorderTx) Commit() error   { return nil }
+func (recorderTx) Rollback() error { return nil }
+
+type recorderStmt struct {
+	d     *copyRecorder
+	query string
+}
+
+func (s recorderStmt) Close() error  { return nil }
+func (s recorderStmt) NumInput() int { return -1 }
+
+// Exec records a row for a COPY statement, as sent by pq.CopyIn, or
+// the statement itself otherwise. A COPY statement executed without
+// arguments ends the copy, and is not recorded.
+func (s recorderStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !strings.HasPrefix(s.query, "COPY ") {
+		s.d.stmts = append(s.d.stmts, s.query)
+		return driver.RowsAffected(0), nil
+	}
+	if len(args) == 0 {
+		return driver.RowsAffected(0), nil
+	}
+	table, err := strconv.Unquote(strings.Fields(s.query)[1])
+	if err != nil {
+		return nil, err
+	}
+	s.d.rows[table] = append(s.d.rows[table], args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s recorderStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("queries aren't supported")
+}
+
+// TestPostgresCopy checks the statements and rows a Postgres sink
+// sends, without a server.
+func TestPostgresCopy(t *testing.T) {
+	defer func(name string) { postgresDriver = name }(postgresDriver)
+	postgresDriver = "postgres-recorder"
+
+	home := data.MakeAddress("https://example.com/")
+	results := []*data.Result{
+		{
+			Address:    home,
+			StatusCode: 200,
+			Links:      []*data.Link{data.MakeLink(home, "/a", "A", "nofollow noopener")},
+			Header:     []*data.Pair{{K: "Content-Type", V: "text/html"}},
+		},
+		{
+			Address:    data.MakeAddress("https://example.com/a"),
+			StatusCode: 404,
+		},
+	}
+	column := func(table schema.Table, name string) int {
+		for i, c := range table.Columns {
+			if c.Name == name {
+				return i
+			}
+		}
+		t.Fatalf("no column %s in %s", name, table.Name)
+		return -1
+	}
+
+	for _, normalized := range []bool{false, true} {
+		*recorder = copyRecorder{rows: make(map[string][][]driver.Value)}
+		s, err := NewPostgres("", PostgresOptions{Normalized: normalized, BatchSize: 1})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		for _, r := range results {
+			if err := s.Write(r); err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+		if err := s.Close(); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		if len(recorder.stmts) != 1 || recorder.stmts[0] != schema.PostgresDDL("crawl", normalized) {
+			t.Errorf("normalized=%v: expected the DDL to be executed, got %q", normalized, recorder.stmts)
+		}
+		tables := schema.Tables("crawl", normalized)
+		pages := recorder.rows["crawl"]
+		if len(pages) != len(results) {
+			t.Fatalf("normalized=%v: expected %d rows, got %d", normalized, len(results), len(pages))
+		}
+		if got := pages[1][column(tables[0], "status_code")]; got != int64(404) {
+			t.Errorf("normalized=%v: expected status code 404, got %v", normalized, got)
+		}
+
+		if !normalized {
+			var links []data.Link
+			if err := json.Unmarshal([]byte(pages[0][column(tables[0], "links")].(string)), &links); err != nil {
+				t.Fatalf("%v", err)
+			}
+			if len(links) != 1 || links[0].Href != "/a" {
+				t.Errorf("unexpected links %v", links)
+			}
+			if got := pages[1][column(tables[0], "links")]; got != nil {
+				t.Errorf("expected NULL links, got %v", got)
+			}
+			continue
+		}
+		if got := pages[0][column(tables[0], "address_full")]; got != home.Full {
+			t.Errorf("expected address %s, got %v", home.Full, got)
+		}
+		var table schema.Table
+		for _, tb := range tables {
+			if tb.Name == "crawl_links" {
+				table = tb
+			}
+		}
+		links := recorder.rows[table.Name]
+		if len(links) != 1 {
+			t.Fatalf("expected 1 link, got %d", len(links))
+		}
+		if got := links[0][column(table, "page")]; got != home.Full {
+			t.Errorf("expected page %s, got %v", home.Full, got)
+		}
+		if got := links[0][column(table, "rel")]; got != `{"nofollow","noopener"}` {
+			t.Errorf("expected rel array, got %v", got)
+		}
+	}
+}
+
+// TestPostgres writes to the database at the connection URL in the
+// environment variable CRAWL_TEST_POSTGRES, if it is set, as in
+// postgres://postgres@localhost/crawl_test?sslmode=disable.
+func TestPostgres(t *testing.T) {
+	conn := os.Getenv("CRAWL_TEST_POSTGRES")
+	if conn == "" {
+		t.Skip("CRAWL_TEST_POSTGRES not set")
+	}
+	db, err := sql.Open("postgres", conn)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer db.Close()
+
+	home := data.MakeAddress("https://example.com/")
+	r := &data.Result{
+		Address:    home,
+		StatusCode: 200,
+		Links:      []*data.Link{data.MakeLink(home, "/a", "A", "nofollow noopener")},
+		Header:     []*data.Pair{{K: "Content-Type", V: "text/html"}},
+	}
+	tests := []struct {
+		normalized bool
+		query      string
+	}{
+		{false, "SELECT COUNT(*) FROM crawl_test WHERE address->>'Full' = $1"},
+		{true, "SELECT COUNT(*) FROM crawl_test_links WHERE page = $1 AND 'noopener' = ANY(rel)"},
+	}
+	for _, tt := range tests {
+		drop := func() {
+			for _, table := range schema.Tables("crawl_test", tt.normalized) {
+				db.Exec("DROP TABLE IF EXISTS " + schema.QuoteIdentifier(table.Name))
+			}
+		}
+		drop()
+		s, err := NewPostgres(conn, PostgresOptions{Table: "crawl_test", Normalized: tt.normalized})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := s.Write(r); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := s.Close(); err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		var n int
+		if err := db.QueryRow(tt.query, home.Full).Scan(&n); err != nil || n != 1 {
+			t.Errorf("normalized=%v: want 1 row, got %d (%v)", tt.normalized, n, err)
+		}
+		drop()
+	}
+}
@@ -0,0 +1,266 @@
+package output
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressionExts maps the names of the supported compression
+// formats to the extensions of the files they produce.
+var compressionExts = map[string]string{
+	"gzip": ".gz",
+	"zstd": ".zst",
+}
+
+// A rotator is a writer of records that compresses its output and
+// spreads it over numbered files, starting a new file when the
+// current one holds enough records or bytes. With a name of
+// "crawl.jsonl" and gzip compression, the files are
+// crawl-00001.jsonl.gz, crawl-00002.jsonl.gz, and so on, which
+// BigQuery can load with the wildcard crawl-*.jsonl.gz. When rotation
+// is enabled, a manifest listing the files and the number of records
+// in each is written to crawl-manifest.json when the rotator is
+// closed.
+//
+// The user of a rotator calls EndRecord after writing each record;
+// files are only rotated between records.
+type rotator struct {
+	base, ext  string
+	compress   string
+	maxRecords int64
+	maxBytes   int64
+	stdout     bool
+
+	// The current file, and the writers layered on top of it. zw
+	// is nil if output isn't compressed.
+	f   io.WriteCloser
+	cw  *countingWriter
+	bw  *bufio.Writer
+	zw  compressor
+	out io.Writer
+
+	records  int64
+	total    int64
+	manifest []manifestFile
+}
+
+// A compressor is a writer that compresses its input, like
+// *gzip.Writer.
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+type manifestFile struct {
+	Name    string
+	Records int64
+	Bytes   int64
+}
+
+type manifest struct {
+	Files   []manifestFile
+	Records int64
+}
+
+// newRotator returns a rotator writing to files named after dest,
+// which is a file name whose extension, like ".jsonl", is kept for
+// each file. compress is empty or names one of compressionExts. If
+// maxRecords and maxBytes are both zero, all records are written to a
+// single file named dest (plus the extension of the compression
+// format, if it doesn't end with it), or to stdout if dest is empty.
+func newRotator(dest, compress string, maxRecords, maxBytes int64) (*rotator, error) {
+	if compress != "" {
+		if _, ok := compressionExts[compress]; !ok {
+			return nil, fmt.Errorf("unknown compression %q (expected gzip or zstd)", compress)
+		}
+	}
+	r := &rotator{
+		compress:   compress,
+		maxRecords: maxRecords,
+		maxBytes:   maxBytes,
+	}
+	switch {
+	case dest == "" && r.rotating():
+		return nil, fmt.Errorf("rotating output needs a destination file")
+	case dest == "":
+		r.stdout = true
+	default:
+		r.base = strings.TrimSuffix(dest, compressionExts[compress])
+		r.ext = filepath.Ext(r.base)
+		r.base = strings.TrimSuffix(r.base, r.ext)
+	}
+	// Open the first file now, so that errors like a missing
+	// directory are reported before the crawl starts.
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotator) rotating() bool {
+	return r.maxRecords > 0 || r.maxBytes > 0
+}
+
+// name returns the name of the current file.
+func (r *rotator) name() string {
+	name := r.base
+	if r.rotating() {
+		name += fmt.Sprintf("-%05d", len(r.manifest))
+	}
+	return name + r.ext + compressionExts[r.compress]
+}
+
+func (r *rotator) open() error {
+	var err error
+	r.manifest = append(r.manifest, manifestFile{})
+	if r.stdout {
+		r.f, err = createOrStdout("")
+	} else {
+		r.f, err = createOrStdout(r.name())
+		r.manifest[len(r.manifest)-1].Name = filepath.Base(r.name())
+	}
+	if err != nil {
+		return err
+	}
+	r.cw = &countingWriter{w: r.f}
+	r.bw = bufio.NewWriter(r.cw)
+	r.out = r.bw
+	switch r.compress {
+	case "gzip":
+		r.zw = gzip.NewWriter(r.bw)
+		r.out = r.zw
+	case "zstd":
+		if r.zw, err = zstd.NewWriter(r.bw); err != nil {
+			r.f.Close()
+			return err
+		}
+		r.out = r.zw
+	}
+	r.records = 0
+	return nil
+}
+
+// closeFile finishes the current file, and records its size in the
+// manifest.
+func (r *rotator) closeFile() error {
+	if r.f == nil {
+		return nil
+	}
+	f := r.f
+	r.f = nil
+	if r.zw != nil {
+		if err := r.zw.Close(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := r.bw.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	m := &r.manifest[len(r.manifest)-1]
+	m.Records = r.records
+	m.Bytes = r.cw.n
+	return f.Close()
+}
+
+func (r *rotator) Write(p []byte) (int, error) {
+	if r.f == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	return r.out.Write(p)
+}
+
+// EndRecord marks the end of a record, and closes the current file if
+// it is full. The next file is opened when it is first written to,
+// so that no empty file is left at the end.
+func (r *rotator) EndRecord() error {
+	r.records++
+	r.total++
+	if !r.rotating() {
+		return nil
+	}
+	full := r.maxRecords > 0 && r.records >= r.maxRecords
+	if r.maxBytes > 0 {
+		// The compressor may hold some bytes back, so the size
+		// of a file is approximate.
+		full = full || r.cw.n+int64(r.bw.Buffered()) >= r.maxBytes
+	}
+	if !full {
+		return nil
+	}
+	return r.closeFile()
+}
+
+// Flush writes any buffered output, so that a partial file can be
+// read while the crawl goes on.
+func (r *rotator) Flush() error {
+	if r.f == nil {
+		return nil
+	}
+	if r.zw != nil {
+		if err := r.zw.Flush(); err != nil {
+			return err
+		}
+	}
+	return r.bw.Flush()
+}
+
+func (r *rotator) Close() error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	if !r.rotating() {
+		return nil
+	}
+	m := manifest{Files: r.manifest, Records: r.total}
+	b, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.base+"-manifest.json", append(b, '\n'), 0644)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// parseSize parses a number of bytes, like 1000, optionally with a
+// suffix of K, M or G for multiples of 1024, like 512M.
+func parseSize(size string) (int64, error) {
+	s, mult := size, int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		mult = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		mult = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		mult = 1 << 30
+	}
+	if mult > 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("bad size %q", size)
+	}
+	return n * mult, nil
+}
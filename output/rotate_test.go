@@ -0,0 +1,165 @@
+package output
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+	"github.com/klauspost/compress/zstd"
+)
+
+func writeResults(t *testing.T, spec string, n int) {
+	s, err := Open(spec)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for i := 0; i < n; i++ {
+		r := &data.Result{Address: data.MakeAddress("https://example.com/" + strings.Repeat("a", i))}
+		if err := s.Write(r); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("%v", err)
+	}
+}
+
+// countLines returns the number of lines in the file at path, after
+// decompressing it with newReader.
+func countLines(t *testing.T, path string, newReader func(io.Reader) (io.Reader, error)) int {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer f.Close()
+	r, err := newReader(f)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	return strings.Count(string(b), "\n")
+}
+
+func gunzip(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+func TestRotateRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, "crawl")
+	writeResults(t, "ndjson:"+base+".jsonl?compress=gzip&records=2", 5)
+
+	names, _ := filepath.Glob(base + "-*.jsonl.gz")
+	want := []string{"crawl-00001.jsonl.gz", "crawl-00002.jsonl.gz", "crawl-00003.jsonl.gz"}
+	if len(names) != len(want) {
+		t.Fatalf("got files %v, want %v", names, want)
+	}
+	for i, name := range names {
+		if filepath.Base(name) != want[i] {
+			t.Errorf("got file %s, want %s", name, want[i])
+		}
+	}
+
+	b, err := ioutil.ReadFile(base + "-manifest.json")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if m.Records != 5 || len(m.Files) != 3 {
+		t.Fatalf("unexpected manifest: %s", b)
+	}
+	for i, f := range m.Files {
+		if f.Name != want[i] {
+			t.Errorf("manifest file %d is %s, want %s", i, f.Name, want[i])
+		}
+		if n := countLines(t, filepath.Join(dir, f.Name), gunzip); int64(n) != f.Records {
+			t.Errorf("%s has %d records, manifest says %d", f.Name, n, f.Records)
+		}
+		info, _ := os.Stat(filepath.Join(dir, f.Name))
+		if info.Size() != f.Bytes {
+			t.Errorf("%s has %d bytes, manifest says %d", f.Name, info.Size(), f.Bytes)
+		}
+	}
+}
+
+func TestRotateSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Every record is bigger than a byte, so each is in a file of
+	// its own, and there is no empty file at the end.
+	base := filepath.Join(dir, "crawl")
+	writeResults(t, "ndjson:"+base+".jsonl?size=1", 3)
+	names, _ := filepath.Glob(base + "-*.jsonl")
+	if len(names) != 3 {
+		t.Fatalf("got files %v, want 3", names)
+	}
+	plain := func(r io.Reader) (io.Reader, error) { return r, nil }
+	for _, name := range names {
+		if n := countLines(t, name, plain); n != 1 {
+			t.Errorf("%s has %d records, want 1", name, n)
+		}
+	}
+}
+
+func TestCompressZstd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, "crawl")
+	writeResults(t, "ndjson:"+base+".jsonl?compress=zstd", 3)
+	unzstd := func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }
+	if n := countLines(t, base+".jsonl.zst", unzstd); n != 3 {
+		t.Errorf("got %d records, want 3", n)
+	}
+	if _, err := os.Stat(base + "-manifest.json"); err == nil {
+		t.Errorf("manifest written without rotation")
+	}
+}
+
+func TestRotateOptions(t *testing.T) {
+	for _, spec := range []string{
+		"ndjson?records=10",
+		"ndjson:x.jsonl?compress=nonsense",
+		"ndjson:x.jsonl?records=none",
+		"ndjson:x.jsonl?size=10X",
+	} {
+		if s, err := Open(spec); err == nil {
+			s.Close()
+			t.Errorf("%s should trigger error", spec)
+		}
+	}
+	if _, err := os.Stat("x.jsonl"); err == nil {
+		os.Remove("x.jsonl")
+		t.Errorf("bad options should not create a file")
+	}
+
+	for s, want := range map[string]int64{"100": 100, "2K": 2048, "512M": 512 << 20, "1G": 1 << 30} {
+		if got, err := parseSize(s); err != nil || got != want {
+			t.Errorf("parseSize(%s) = %d, %v; want %d", s, got, err, want)
+		}
+	}
+}
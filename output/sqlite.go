@@ -0,0 +1,203 @@
+package output
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+	"github.com/benjaminestes/crawl/schema"
+
+	// Register the pure-Go "sqlite" driver with database/sql.
+	_ "modernc.org/sqlite"
+)
+
+// SQLite is a Sink that writes results into normalized tables of a
+// SQLite database, for local analysis without BigQuery. The tables
+// are those described by schema.Tables("pages", true): pages are
+// written to the pages table, keyed by address_full, and repeated
+// records, like links and response headers, to tables of their own,
+// like pages_links and pages_header, whose page column refers to the
+// URL of the page they came from.
+//
+// Results are written in a transaction that is committed on every
+// Flush.
+type SQLite struct {
+	db     *sql.DB
+	tx     *sql.Tx
+	tables []schema.Table
+	stmts  []*sql.Stmt
+}
+
+// NewSQLite returns a SQLite sink writing to a new database at path.
+// If a file already exists at path, it is an error, unless replace is
+// true, in which case the file is replaced.
+func NewSQLite(path string, replace bool) (*SQLite, error) {
+	if _, err := os.Stat(path); err == nil {
+		if !replace {
+			return nil, fmt.Errorf("%s already exists (use sqlite:%s?replace=1 to replace it)", path, path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema.SQLiteDDL("pages")); err != nil {
+		db.Close()
+		return nil, err
+	}
+	s := &SQLite{
+		db:     db,
+		tables: schema.Tables("pages", true),
+	}
+	if err := s.begin(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// openSQLite opens a SQLite sink. The replace option allows an
+// existing file at dest to be replaced, as in sqlite:crawl.db?replace=1.
+func openSQLite(dest string, opts url.Values) (Sink, error) {
+	if err := checkOptions("sqlite", opts, "replace"); err != nil {
+		return nil, err
+	}
+	if dest == "" {
+		return nil, errors.New("sqlite output needs a destination file, as in sqlite:crawl.db")
+	}
+	replace := false
+	if s := opts.Get("replace"); s != "" {
+		var err error
+		if replace, err = strconv.ParseBool(s); err != nil {
+			return nil, fmt.Errorf("bad sqlite replace option %q", s)
+		}
+	}
+	return NewSQLite(dest, replace)
+}
+
+// OpenSQLiteDB opens a database written by a SQLite sink for
+// querying.
+func OpenSQLiteDB(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return sql.Open("sqlite", path)
+}
+
+// begin starts a transaction and prepares the insert statements
+// within it, one for each table.
+func (s *SQLite) begin() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	s.tx = tx
+	s.stmts = nil
+	for _, t := range s.tables {
+		var names, params []string
+		for _, c := range t.Columns {
+			names = append(names, schema.QuoteIdentifier(c.Name))
+			params = append(params, "?")
+		}
+		insert := "INSERT"
+		if t.Field == nil {
+			// A page crawled twice replaces the earlier
+			// result.
+			insert = "INSERT OR REPLACE"
+		}
+		stmt, err := tx.Prepare(fmt.Sprintf("%s INTO %s (%s) VALUES (%s)",
+			insert, schema.QuoteIdentifier(t.Name),
+			strings.Join(names, ", "), strings.Join(params, ", ")))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		s.stmts = append(s.stmts, stmt)
+	}
+	return nil
+}
+
+func (s *SQLite) Write(r *data.Result) error {
+	v := reflect.ValueOf(r).Elem()
+	for i, t := range s.tables {
+		if t.Field == nil {
+			if _, err := s.stmts[i].Exec(sqlRow(v, t.Columns, sqliteValue)...); err != nil {
+				return fmt.Errorf("writing to %s: %v", t.Name, err)
+			}
+			continue
+		}
+		// The first column of the table is the page, which
+		// isn't a field of the elements.
+		page := fullAddress(r.Address)
+		elems := v.Field(t.Field.Index)
+		for j := 0; j < elems.Len(); j++ {
+			row := append([]interface{}{page}, sqlRow(elems.Index(j), t.Columns[1:], sqliteValue)...)
+			if _, err := s.stmts[i].Exec(row...); err != nil {
+				return fmt.Errorf("writing to %s: %v", t.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sqlRow returns the values of columns in v, which is a struct or a
+// pointer to one, as arguments to a SQL statement. Missing values,
+// including nil pointers and slices, are NULL; value returns the
+// argument for each of the others, whose column is c.
+func sqlRow(v reflect.Value, columns []schema.Column, value func(c schema.Column, f reflect.Value) interface{}) []interface{} {
+	row := make([]interface{}, len(columns))
+	for i, c := range columns {
+		f := fieldByIndex(v, c.Index)
+		switch {
+		case !f.IsValid():
+			// A nil pointer along the way.
+		case (f.Kind() == reflect.Ptr || f.Kind() == reflect.Slice) && f.IsNil():
+			// NULL, rather than an empty value.
+		default:
+			row[i] = value(c, f)
+		}
+	}
+	return row
+}
+
+// sqliteValue returns the value of f in column c for SQLite, which
+// has no arrays: repeated scalars are joined by spaces.
+func sqliteValue(c schema.Column, f reflect.Value) interface{} {
+	if c.Repeated {
+		return formatValue(f)
+	}
+	return f.Interface()
+}
+
+// Flush commits the results written so far, and starts a new
+// transaction for the results that follow.
+func (s *SQLite) Flush() error {
+	if err := s.tx.Commit(); err != nil {
+		return err
+	}
+	return s.begin()
+}
+
+func (s *SQLite) Close() error {
+	if err := s.tx.Commit(); err != nil {
+		s.db.Close()
+		return err
+	}
+	return s.db.Close()
+}
+
+func fullAddress(a *data.Address) string {
+	if a == nil {
+		return ""
+	}
+	return a.Full
+}
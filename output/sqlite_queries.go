@@ -0,0 +1,78 @@
+package output
+
+import "sort"
+
+// sqliteQueries are versions of the queries in the sql directory,
+// ported to the tables written by the SQLite sink.
+var sqliteQueries = map[string]string{
+	// Pages linked to by pages in the crawl, and how many times.
+	// Because a page can be linked to without being crawled, some
+	// rows have no status code.
+	"inlinks": `
+SELECT
+	l.address_full AS url,
+	pages.status_code AS status_code,
+	COUNT(*) AS inlinks
+FROM pages_links l LEFT JOIN pages ON l.address_full = pages.address_full
+WHERE l.element IN ('a', 'area')
+GROUP BY l.address_full
+ORDER BY inlinks DESC`,
+
+	// Pages with a status of 200 whose title is shared with other
+	// pages, and the number of pages with that title.
+	"duplicate_title": `
+WITH r AS (
+	SELECT title, COUNT(*) AS n
+	FROM pages
+	GROUP BY title
+)
+SELECT
+	pages.address_full AS url,
+	r.title AS title,
+	r.n AS n
+FROM pages JOIN r USING (title)
+WHERE r.title != '' AND r.n > 1 AND pages.status_code = 200
+ORDER BY r.n DESC, r.title DESC, pages.address_full`,
+
+	// Whether each page has a noindex directive that applies to
+	// all crawlers, or to Googlebot specifically, in either a
+	// robots meta tag or an X-Robots-Tag header.
+	"noindex": `
+SELECT
+	address_full AS url,
+	EXISTS (SELECT 1 FROM pages_robots_directives d
+		WHERE d.page = pages.address_full AND d.noindex
+		AND d.user_agent = '') AS noindex,
+	EXISTS (SELECT 1 FROM pages_robots_directives d
+		WHERE d.page = pages.address_full AND d.noindex
+		AND d.user_agent IN ('', 'googlebot')) AS googlebot_noindex
+FROM pages
+ORDER BY address_full`,
+
+	// Whether each page has a self-referencing canonical tag. A
+	// canonical with an empty href means the page didn't declare
+	// one.
+	"self_canonical": `
+SELECT
+	address_full AS url,
+	COALESCE(canonical_href != '' AND canonical_address_full = address_full, 0) AS self_canonical
+FROM pages
+ORDER BY address_full`,
+}
+
+// SQLiteQuery returns the text of the named query for a database
+// written by the SQLite sink.
+func SQLiteQuery(name string) (string, bool) {
+	q, ok := sqliteQueries[name]
+	return q, ok
+}
+
+// SQLiteQueries returns the names of the available queries.
+func SQLiteQueries() []string {
+	var names []string
+	for name := range sqliteQueries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,172 @@
+package output
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// queryRows runs q against db, and returns the rows as strings.
+func queryRows(db *sql.DB, q string) ([][]string, error) {
+	rows, err := db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result [][]string
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make([]string, len(cols))
+		for i, v := range vals {
+			if v != nil {
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func TestSQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "crawl.db")
+	s, err := Open("sqlite:" + path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	home := data.MakeAddress("https://example.com/")
+	a := data.MakeAddress("https://example.com/a")
+	link := func(base *data.Address, href string) *data.Link {
+		l := data.MakeLink(base, href, "", "")
+		l.Element = "a"
+		return l
+	}
+	results := []*data.Result{
+		{
+			Address:    home,
+			StatusCode: 200,
+			Title:      "Example",
+			Canonical:  data.MakeCanonical(home, "/"),
+			Links: []*data.Link{
+				link(home, "/a"),
+				link(home, "/b"),
+			},
+			Header: []*data.Pair{{K: "Content-Type", V: "text/html"}},
+		},
+		{
+			Address:          a,
+			StatusCode:       200,
+			Title:            "Example",
+			Canonical:        data.MakeCanonical(a, ""),
+			Links:            []*data.Link{link(a, "/b")},
+			RobotsDirectives: data.MakeRobotsDirectives("meta", "googlebot: noindex"),
+		},
+	}
+	for _, r := range results {
+		if err := s.Write(r); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	db, err := OpenSQLiteDB(path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		query string
+		want  [][]string
+	}{
+		{"inlinks", [][]string{
+			{"https://example.com/b", "", "2"},
+			{"https://example.com/a", "200", "1"},
+		}},
+		{"duplicate_title", [][]string{
+			{"https://example.com/", "Example", "2"},
+			{"https://example.com/a", "Example", "2"},
+		}},
+		{"noindex", [][]string{
+			{"https://example.com/", "0", "0"},
+			{"https://example.com/a", "0", "1"},
+		}},
+		{"SELECT page, address_full, rel, dom_path FROM pages_links WHERE page = 'https://example.com/a'", [][]string{
+			{"https://example.com/a", "https://example.com/b", "", ""},
+		}},
+		{"self_canonical", [][]string{
+			{"https://example.com/", "1"},
+			{"https://example.com/a", "0"},
+		}},
+		{"SELECT page, k, v FROM pages_header", [][]string{
+			{"https://example.com/", "Content-Type", "text/html"},
+		}},
+	}
+	for _, tt := range tests {
+		q, ok := SQLiteQuery(tt.query)
+		if !ok {
+			q = tt.query
+		}
+		got, err := queryRows(db, q)
+		if err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSQLiteNeedsDestination(t *testing.T) {
+	if _, err := Open("sqlite"); err == nil {
+		t.Errorf("sqlite without destination should trigger error")
+	}
+}
+
+func TestSQLiteExists(t *testing.T) {
+	f, err := ioutil.TempFile("", "crawl.db")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	if _, err := Open("sqlite:" + f.Name()); err == nil {
+		t.Errorf("sqlite should refuse to replace an existing file")
+	}
+	s, err := Open("sqlite:" + f.Name() + "?replace=1")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("%v", err)
+	}
+}
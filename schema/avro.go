@@ -0,0 +1,67 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// Avro returns an Apache Avro schema for results. Nested records are
+// named after their Go types, and each is defined where it first
+// appears and referred to by name elsewhere, as Avro requires.
+// Records and repeated fields are nullable.
+func Avro() []byte {
+	defined := make(map[string]bool)
+	s := avroRecord(reflect.TypeOf(data.Result{}), defined)
+	s["namespace"] = "crawl"
+	j, _ := json.MarshalIndent(s, "", "\t")
+	return j
+}
+
+func avroRecord(t reflect.Type, defined map[string]bool) map[string]interface{} {
+	defined[t.Name()] = true
+	var fields []interface{}
+	for _, f := range Fields(t) {
+		name, _ := f.JSONName()
+		var typ interface{}
+		switch {
+		case f.IsRecord() && defined[f.Type.Name()]:
+			typ = f.Type.Name()
+		case f.IsRecord():
+			typ = avroRecord(f.Type, defined)
+		default:
+			typ = avroType(f.Type)
+		}
+		if f.Repeated {
+			typ = map[string]interface{}{"type": "array", "items": typ}
+		}
+		field := map[string]interface{}{"name": name, "type": typ}
+		if d := f.Tag.Get("description"); d != "" {
+			field["doc"] = d
+		}
+		if f.Repeated || f.IsRecord() {
+			field["type"] = []interface{}{"null", typ}
+			field["default"] = nil
+		}
+		fields = append(fields, field)
+	}
+	return map[string]interface{}{
+		"type":   "record",
+		"name":   t.Name(),
+		"fields": fields,
+	}
+}
+
+func avroType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Float64:
+		return "double"
+	case reflect.Int, reflect.Int64:
+		return "long"
+	case reflect.Bool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
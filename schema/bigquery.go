@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"reflect"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// bigQuerySchema returns the BigQuery schema of the fields. Names are
+// those of the JSON encoding, and descriptions come from the
+// description tag of each field. The mode tag sets the mode of a
+// field that isn't repeated; by default, it is NULLABLE.
+func bigQuerySchema(fields []Field) []schemaItem {
+	var items []schemaItem
+	for _, f := range fields {
+		name, _ := f.JSONName()
+		s := schemaItem{
+			Name:        name,
+			Type:        bigQueryType(f.Type),
+			Mode:        f.Tag.Get("mode"),
+			Description: f.Tag.Get("description"),
+		}
+		if f.Repeated {
+			s.Mode = "REPEATED"
+		} else if s.Mode == "" {
+			s.Mode = "NULLABLE"
+		}
+		if f.IsRecord() {
+			s.Fields = bigQuerySchema(f.Fields)
+		}
+		items = append(items, s)
+	}
+	return items
+}
+
+func bigQueryType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Float64:
+		return "FLOAT64"
+	case reflect.Int, reflect.Int64:
+		return "INT64"
+	case reflect.Bool:
+		return "BOOL"
+	case reflect.Struct:
+		return "RECORD"
+	default:
+		return "STRING"
+	}
+}
+
+func resultSchema() []schemaItem {
+	return bigQuerySchema(Fields(reflect.TypeOf(data.Result{})))
+}
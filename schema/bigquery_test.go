@@ -0,0 +1,83 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// checkEncoding checks that the JSON value v, encoded from a struct
+// with every field set, has exactly the fields in items, with the
+// types they declare.
+func checkEncoding(t *testing.T, path string, items []schemaItem, v map[string]interface{}) {
+	byName := make(map[string]schemaItem)
+	for _, item := range items {
+		byName[item.Name] = item
+		if _, ok := v[item.Name]; !ok {
+			t.Errorf("%s: schema field %s not in JSON", path, item.Name)
+		}
+	}
+	for k, val := range v {
+		item, ok := byName[k]
+		if !ok {
+			t.Errorf("%s: JSON field %s not in schema", path, k)
+			continue
+		}
+		vals := []interface{}{val}
+		if item.Mode == "REPEATED" {
+			arr, ok := val.([]interface{})
+			if !ok {
+				t.Errorf("%s.%s: want array, got %v", path, k, val)
+				continue
+			}
+			vals = arr
+		}
+		for _, val := range vals {
+			checkValue(t, path+"."+k, item, val)
+		}
+	}
+}
+
+func checkValue(t *testing.T, path string, item schemaItem, v interface{}) {
+	ok := false
+	switch item.Type {
+	case "RECORD":
+		var obj map[string]interface{}
+		if obj, ok = v.(map[string]interface{}); ok {
+			checkEncoding(t, path, item.Fields, obj)
+		}
+	case "STRING":
+		_, ok = v.(string)
+	case "BOOL":
+		_, ok = v.(bool)
+	case "INT64":
+		var n float64
+		n, ok = v.(float64)
+		ok = ok && n == float64(int64(n))
+	case "FLOAT64":
+		_, ok = v.(float64)
+	}
+	if !ok {
+		t.Errorf("%s: want %s, got %v", path, item.Type, v)
+	}
+}
+
+func TestBigQueryMatchesEncoding(t *testing.T) {
+	var items []schemaItem
+	if err := json.Unmarshal(BigQueryJSON(), &items); err != nil {
+		t.Fatalf("%v", err)
+	}
+	checkEncoding(t, "Result", items, populatedResult(t))
+}
+
+func TestBigQueryDescriptions(t *testing.T) {
+	var check func(prefix string, items []schemaItem)
+	check = func(prefix string, items []schemaItem) {
+		for _, item := range items {
+			if item.Description == "" {
+				t.Errorf("field %s%s has no description tag", prefix, item.Name)
+			}
+			check(prefix+item.Name+".", item.Fields)
+		}
+	}
+	check("", resultSchema())
+}
@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// ClickHouseDDL returns a statement creating a MergeTree table
+// called name, whose columns mirror the JSON output of a crawl:
+// records are Tuples and repeated fields are Arrays, with the same
+// field names. The JSON output can be inserted into it in the
+// JSONEachRow format, with omitted fields taking default values.
+func ClickHouseDDL(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", clickHouseIdentifier(name))
+	fields := Fields(reflect.TypeOf(data.Result{}))
+	for i, f := range fields {
+		n, _ := f.JSONName()
+		fmt.Fprintf(&b, "\t%s %s", clickHouseIdentifier(n), clickHouseType(f))
+		if i < len(fields)-1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(") ENGINE = MergeTree ORDER BY tuple();\n")
+	return b.String()
+}
+
+func clickHouseType(f Field) string {
+	var typ string
+	if f.IsRecord() {
+		var members []string
+		for _, g := range f.Fields {
+			n, _ := g.JSONName()
+			members = append(members, clickHouseIdentifier(n)+" "+clickHouseType(g))
+		}
+		typ = "Tuple(" + strings.Join(members, ", ") + ")"
+	} else {
+		switch f.Type.Kind() {
+		case reflect.Float64:
+			typ = "Float64"
+		case reflect.Int, reflect.Int64:
+			typ = "Int64"
+		case reflect.Bool:
+			typ = "Bool"
+		default:
+			typ = "String"
+		}
+	}
+	if f.Repeated {
+		typ = "Array(" + typ + ")"
+	}
+	return typ
+}
+
+// clickHouseIdentifier quotes name for use as an identifier in
+// ClickHouse, which uses backquotes.
+func clickHouseIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "\\`", -1) + "`"
+}
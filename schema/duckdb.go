@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// DuckDBDDL returns a statement creating a table called name, whose
+// columns mirror the JSON output of a crawl: records are STRUCTs and
+// repeated fields are LISTs, with the same field names. The JSON
+// output can be loaded into it with read_json.
+func DuckDBDDL(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", QuoteIdentifier(name))
+	fields := Fields(reflect.TypeOf(data.Result{}))
+	for i, f := range fields {
+		n, _ := f.JSONName()
+		fmt.Fprintf(&b, "\t%s %s", QuoteIdentifier(n), duckDBType(f))
+		if i < len(fields)-1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(");\n")
+	return b.String()
+}
+
+func duckDBType(f Field) string {
+	var typ string
+	if f.IsRecord() {
+		var members []string
+		for _, g := range f.Fields {
+			n, _ := g.JSONName()
+			members = append(members, QuoteIdentifier(n)+" "+duckDBType(g))
+		}
+		typ = "STRUCT(" + strings.Join(members, ", ") + ")"
+	} else {
+		switch f.Type.Kind() {
+		case reflect.Float64:
+			typ = "DOUBLE"
+		case reflect.Int, reflect.Int64:
+			typ = "BIGINT"
+		case reflect.Bool:
+			typ = "BOOLEAN"
+		default:
+			typ = "VARCHAR"
+		}
+	}
+	if f.Repeated {
+		typ += "[]"
+	}
+	return typ
+}
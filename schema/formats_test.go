@@ -0,0 +1,183 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// populate sets every field reachable from v to a non-zero value,
+// giving slices a single element.
+func populate(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		populate(v.Elem())
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		populate(v.Index(0))
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				populate(v.Field(i))
+			}
+		}
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Int, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Float64:
+		v.SetFloat(0.5)
+	case reflect.Bool:
+		v.SetBool(true)
+	}
+}
+
+// populatedResult returns the JSON encoding of a Result with every
+// field set, decoded into generic values.
+func populatedResult(t *testing.T) map[string]interface{} {
+	var r data.Result
+	populate(reflect.ValueOf(&r).Elem())
+	b, err := json.Marshal(&r)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("%v", err)
+	}
+	return m
+}
+
+// validate checks v against the subset of JSON Schema produced by
+// JSONSchema.
+func validate(t *testing.T, path string, schema map[string]interface{}, v interface{}) {
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		if v == nil {
+			return
+		}
+		schema = anyOf[0].(map[string]interface{})
+	}
+	switch schema["type"] {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: want object, got %v", path, v)
+			return
+		}
+		properties := schema["properties"].(map[string]interface{})
+		for k, val := range obj {
+			p, ok := properties[k]
+			if !ok {
+				t.Errorf("%s: unexpected property %s", path, k)
+				continue
+			}
+			validate(t, path+"."+k, p.(map[string]interface{}), val)
+		}
+		for _, k := range schema["required"].([]interface{}) {
+			if _, ok := obj[k.(string)]; !ok {
+				t.Errorf("%s: missing required property %s", path, k)
+			}
+		}
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			t.Errorf("%s: want array, got %v", path, v)
+			return
+		}
+		for _, val := range arr {
+			validate(t, path+"[]", schema["items"].(map[string]interface{}), val)
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			t.Errorf("%s: want string, got %v", path, v)
+		}
+	case "integer", "number":
+		if _, ok := v.(float64); !ok {
+			t.Errorf("%s: want number, got %v", path, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			t.Errorf("%s: want boolean, got %v", path, v)
+		}
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	var s map[string]interface{}
+	if err := json.Unmarshal(JSONSchema(), &s); err != nil {
+		t.Fatalf("%v", err)
+	}
+	validate(t, "Result", s, populatedResult(t))
+
+	// An empty result omits most fields, but must still be valid.
+	b, _ := json.Marshal(&data.Result{})
+	var empty map[string]interface{}
+	json.Unmarshal(b, &empty)
+	validate(t, "Result", s, empty)
+}
+
+func TestAvro(t *testing.T) {
+	var s map[string]interface{}
+	if err := json.Unmarshal(Avro(), &s); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if s["name"] != "Result" || s["type"] != "record" {
+		t.Errorf("unexpected top-level schema: %v", s)
+	}
+	// Each named type must be defined only once.
+	defined := make(map[string]int)
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if v["type"] == "record" {
+				defined[v["name"].(string)]++
+			}
+			for _, val := range v {
+				walk(val)
+			}
+		case []interface{}:
+			for _, val := range v {
+				walk(val)
+			}
+		}
+	}
+	walk(s)
+	for name, n := range defined {
+		if n != 1 {
+			t.Errorf("record %s defined %d times", name, n)
+		}
+	}
+}
+
+func TestSQLDDL(t *testing.T) {
+	tests := []struct {
+		ddl  string
+		want []string
+	}{
+		{DuckDBDDL("crawl"), []string{
+			`CREATE TABLE IF NOT EXISTS "crawl" (`,
+			`"Address" STRUCT("Full" VARCHAR,`,
+			`"Rel" VARCHAR[]`,
+			`"Header" STRUCT("K" VARCHAR, "V" VARCHAR)[]`,
+		}},
+		{ClickHouseDDL("crawl"), []string{
+			"CREATE TABLE IF NOT EXISTS `crawl` (",
+			"`Depth` Int64",
+			"`Rel` Array(String)",
+			"`Header` Array(Tuple(`K` String, `V` String))",
+			") ENGINE = MergeTree ORDER BY tuple();",
+		}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(tt.ddl, want) {
+				t.Errorf("DDL doesn't contain %s:\n%s", want, tt.ddl)
+			}
+		}
+	}
+}
@@ -1,12 +1,15 @@
 // Package scheme is an internal package of the tool Crawl,
 // responsible for automatically generating schema definitions
-// for output files.
+// for output files. Schemas are derived at run time from the fields
+// of data.Result and their tags, so they can't drift from the JSON
+// encoding of results.
 package schema
 
 import "encoding/json"
 
+// BigQueryJSON returns a BigQuery schema for results, in JSON.
 func BigQueryJSON() []byte {
-	// FIXME: Check error
-	j, _ := json.MarshalIndent(bq, "", "\t")
+	// A []schemaItem is always encodable.
+	j, _ := json.MarshalIndent(resultSchema(), "", "\t")
 	return j
 }
@@ -0,0 +1,68 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing each
+// line of the JSON output of a crawl.
+func JSONSchema() []byte {
+	s := jsonSchemaObject(Fields(reflect.TypeOf(data.Result{})))
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s["title"] = "Result"
+	j, _ := json.MarshalIndent(s, "", "\t")
+	return j
+}
+
+func jsonSchemaObject(fields []Field) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+	for _, f := range fields {
+		name, omitempty := f.JSONName()
+		if !omitempty {
+			required = append(required, name)
+		}
+		var typ map[string]interface{}
+		if f.IsRecord() {
+			typ = jsonSchemaObject(f.Fields)
+		} else {
+			typ = map[string]interface{}{"type": jsonSchemaType(f.Type)}
+		}
+		if f.Repeated {
+			typ = map[string]interface{}{"type": "array", "items": typ}
+		}
+		// Nil pointers and slices are encoded as null unless
+		// they are omitted.
+		if f.Repeated || f.IsRecord() {
+			typ = map[string]interface{}{"anyOf": []interface{}{
+				typ, map[string]interface{}{"type": "null"},
+			}}
+		}
+		if d := f.Tag.Get("description"); d != "" {
+			typ["description"] = d
+		}
+		properties[name] = typ
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+func jsonSchemaType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Float64:
+		return "number"
+	case reflect.Int, reflect.Int64:
+		return "integer"
+	case reflect.Bool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,57 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PostgresDDL returns the statements creating the tables described
+// by Tables(name, normalized) in PostgreSQL, if they don't already
+// exist. In normalized form, the tables of repeated records are
+// indexed by page.
+func PostgresDDL(name string, normalized bool) string {
+	var b strings.Builder
+	for _, t := range Tables(name, normalized) {
+		fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", QuoteIdentifier(t.Name))
+		for i, c := range t.Columns {
+			fmt.Fprintf(&b, "\t%s %s", QuoteIdentifier(c.Name), PostgresType(c))
+			if t.Field != nil && i == 0 {
+				b.WriteString(" NOT NULL")
+			}
+			if i < len(t.Columns)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(");\n")
+		if t.Field != nil {
+			fmt.Fprintf(&b, "CREATE INDEX IF NOT EXISTS %s ON %s (%s);\n",
+				QuoteIdentifier(t.Name+"_page"), QuoteIdentifier(t.Name),
+				QuoteIdentifier(t.Columns[0].Name))
+		}
+	}
+	return b.String()
+}
+
+// PostgresType returns the PostgreSQL type of the column c.
+func PostgresType(c Column) string {
+	if c.Nested {
+		return "JSONB"
+	}
+	var typ string
+	switch c.Type.Kind() {
+	case reflect.Float64:
+		typ = "DOUBLE PRECISION"
+	case reflect.Int, reflect.Int64:
+		typ = "BIGINT"
+	case reflect.Bool:
+		typ = "BOOLEAN"
+	default:
+		typ = "TEXT"
+	}
+	if c.Repeated {
+		typ += "[]"
+	}
+	return typ
+}
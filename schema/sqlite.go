@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SQLiteDDL returns the statements creating the tables described by
+// Tables(name, true) in SQLite. The table of results is keyed by
+// address_full, and the tables of repeated records are indexed by
+// page, and by address_full if their records have an address.
+func SQLiteDDL(name string) string {
+	var b strings.Builder
+	for _, t := range Tables(name, true) {
+		fmt.Fprintf(&b, "CREATE TABLE %s (\n", QuoteIdentifier(t.Name))
+		hasAddress := false
+		for i, c := range t.Columns {
+			fmt.Fprintf(&b, "\t%s %s", QuoteIdentifier(c.Name), SQLiteType(c))
+			if t.Field != nil && i == 0 {
+				b.WriteString(" NOT NULL")
+			}
+			if i < len(t.Columns)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+			if c.Name == "address_full" {
+				hasAddress = true
+			}
+		}
+		b.WriteString(");\n")
+		switch {
+		case t.Field == nil:
+			fmt.Fprintf(&b, "CREATE UNIQUE INDEX %s ON %s (address_full);\n",
+				QuoteIdentifier(t.Name+"_address_full"), QuoteIdentifier(t.Name))
+		default:
+			fmt.Fprintf(&b, "CREATE INDEX %s ON %s (page);\n",
+				QuoteIdentifier(t.Name+"_page"), QuoteIdentifier(t.Name))
+			if hasAddress {
+				fmt.Fprintf(&b, "CREATE INDEX %s ON %s (address_full);\n",
+					QuoteIdentifier(t.Name+"_address_full"), QuoteIdentifier(t.Name))
+			}
+		}
+	}
+	return b.String()
+}
+
+// SQLiteType returns the SQLite type of the column c. Booleans are
+// stored as integers, and repeated scalars as text, their values
+// separated by spaces.
+func SQLiteType(c Column) string {
+	if c.Nested || c.Repeated {
+		return "TEXT"
+	}
+	switch c.Type.Kind() {
+	case reflect.Float64:
+		return "REAL"
+	case reflect.Int, reflect.Int64, reflect.Bool:
+		return "INTEGER"
+	default:
+		return "TEXT"
+	}
+}
@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/benjaminestes/crawl/crawler/data"
+)
+
+// A Table describes a table of a relational database holding
+// results, or the elements of one of their repeated records.
+type Table struct {
+	Name    string
+	Columns []Column
+
+	// Field is the repeated record whose elements are the rows of
+	// the table, or nil if the rows are results. The first column
+	// of such a table is Page, holding the URL of the result the
+	// row came from.
+	Field *Field
+}
+
+// A Column describes a column of a Table. Path is the dotted name of
+// the column's field in Go, like Address.Full. Index is the sequence
+// of field indexes leading to the column's value from the struct of
+// its row, following pointers. If Nested is true, the value is a record or
+// a repeated record, stored as JSON. If Repeated is true, the value
+// is a repeated scalar, stored as an array.
+type Column struct {
+	Name     string
+	Path     string
+	Index    []int
+	Type     reflect.Type
+	Repeated bool
+	Nested   bool
+}
+
+// Tables returns the tables in which results are stored by SQL
+// databases. The table of results is called name. If normalized is
+// true, records are flattened into columns, as in address_full, and
+// repeated records are stored in tables of their own, named like
+// name_links. Otherwise, records and repeated records are stored as
+// JSON in a single table.
+func Tables(name string, normalized bool) []Table {
+	fields := Fields(reflect.TypeOf(data.Result{}))
+	if !normalized {
+		var columns []Column
+		for _, f := range fields {
+			c := Column{
+				Name:     SnakeCase(f.Name),
+				Path:     f.Name,
+				Index:    []int{f.Index},
+				Type:     f.Type,
+				Repeated: f.Repeated,
+			}
+			if f.IsRecord() {
+				c.Nested = true
+				c.Repeated = false
+			}
+			columns = append(columns, c)
+		}
+		return []Table{{Name: name, Columns: columns}}
+	}
+
+	tables := []Table{{Name: name, Columns: FlatColumns(fields)}}
+	for i := range fields {
+		f := &fields[i]
+		if !f.Repeated || !f.IsRecord() {
+			continue
+		}
+		page := Column{Name: "page", Type: reflect.TypeOf("")}
+		tables = append(tables, Table{
+			Name:    name + "_" + SnakeCase(f.Name),
+			Columns: append([]Column{page}, FlatColumns(f.Fields)...),
+			Field:   f,
+		})
+	}
+	return tables
+}
+
+// FlatColumns returns a column for every field reachable from fields
+// without passing through a repeated record. The columns of nested
+// records are named after the record, as in address_full.
+func FlatColumns(fields []Field) []Column {
+	return flatColumns(fields, Column{})
+}
+
+// flatColumns is FlatColumns for fields of the record described by
+// parent, whose name, path and index prefix those of its columns.
+func flatColumns(fields []Field, parent Column) []Column {
+	var columns []Column
+	for _, f := range fields {
+		c := Column{
+			Name:     SnakeCase(f.Name),
+			Path:     f.Name,
+			Index:    append(append([]int(nil), parent.Index...), f.Index),
+			Type:     f.Type,
+			Repeated: f.Repeated,
+		}
+		if parent.Path != "" {
+			c.Name = parent.Name + "_" + c.Name
+			c.Path = parent.Path + "." + c.Path
+		}
+		switch {
+		case f.Repeated && f.IsRecord():
+			// These have tables of their own.
+		case f.IsRecord():
+			columns = append(columns, flatColumns(f.Fields, c)...)
+		default:
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// SnakeCase converts a Go field name, like ResponseTimeMs or
+// HTMLLang, to the form conventional in SQL, like response_time_ms or
+// html_lang.
+func SnakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// QuoteIdentifier quotes name for use as an identifier in SQL, so
+// that names like "position" aren't taken for keywords.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,84 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"Address":        "address",
+		"ResponseTimeMs": "response_time_ms",
+		"HTMLLang":       "html_lang",
+		"DOMPath":        "dom_path",
+		"UGC":            "ugc",
+		"H1":             "h1",
+		"SimHash":        "sim_hash",
+	}
+	for in, want := range tests {
+		if got := SnakeCase(in); got != want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTables(t *testing.T) {
+	tables := Tables("crawl", true)
+	byName := make(map[string]Table)
+	for _, t := range tables {
+		byName[t.Name] = t
+	}
+	links, ok := byName["crawl_links"]
+	if !ok {
+		t.Fatalf("no crawl_links table in %v", tables)
+	}
+	if links.Columns[0].Name != "page" {
+		t.Errorf("first column of links is %s, want page", links.Columns[0].Name)
+	}
+	var rel *Column
+	for i, c := range links.Columns {
+		if c.Name == "rel" {
+			rel = &links.Columns[i]
+		}
+	}
+	if rel == nil || !rel.Repeated {
+		t.Errorf("links should have repeated rel column")
+	}
+	for _, c := range byName["crawl"].Columns {
+		if c.Nested {
+			t.Errorf("normalized crawl table has nested column %s", c.Name)
+		}
+	}
+
+	if len(Tables("crawl", false)) != 1 {
+		t.Errorf("jsonb layout should have a single table")
+	}
+}
+
+func TestPostgresDDL(t *testing.T) {
+	ddl := PostgresDDL("crawl", false)
+	for _, want := range []string{
+		`CREATE TABLE IF NOT EXISTS "crawl" (`,
+		`"address" JSONB`,
+		`"links" JSONB`,
+		`"status_code" BIGINT`,
+		`"indexable" BOOLEAN`,
+	} {
+		if !strings.Contains(ddl, want) {
+			t.Errorf("DDL doesn't contain %s:\n%s", want, ddl)
+		}
+	}
+
+	ddl = PostgresDDL("crawl", true)
+	for _, want := range []string{
+		`"address_full" TEXT`,
+		`CREATE TABLE IF NOT EXISTS "crawl_links" (`,
+		`"page" TEXT NOT NULL`,
+		`"rel" TEXT[]`,
+		`CREATE INDEX IF NOT EXISTS "crawl_links_page" ON "crawl_links" ("page");`,
+	} {
+		if !strings.Contains(ddl, want) {
+			t.Errorf("DDL doesn't contain %s:\n%s", want, ddl)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Field describes a field of a struct type, as it is represented in
+// the output of a crawl. Pointers are dereferenced: Type is the type
+// of the field's value, or of its elements if it is Repeated. If
+// that type is a struct, Fields describes its fields, recursively.
+type Field struct {
+	Name     string
+	Index    int
+	Type     reflect.Type
+	Repeated bool
+	Tag      reflect.StructTag
+	Fields   []Field
+}
+
+// IsRecord reports whether the field holds a nested struct.
+func (f Field) IsRecord() bool {
+	return f.Type.Kind() == reflect.Struct
+}
+
+// JSONName returns the name of the field in the JSON encoding of its
+// struct, and whether the field is omitted from it when empty.
+func (f Field) JSONName() (name string, omitempty bool) {
+	opts := strings.Split(f.Tag.Get("json"), ",")
+	name = opts[0]
+	if name == "" {
+		name = f.Name
+	}
+	for _, opt := range opts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty
+}
+
+// Fields returns the exported fields of the struct type t in
+// declaration order. Pointer types are followed to the types they
+// point to.
+func Fields(t reflect.Type) []Field {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		g := t.Field(i)
+		if g.PkgPath != "" {
+			continue
+		}
+		f := Field{
+			Name:  g.Name,
+			Index: i,
+			Type:  g.Type,
+			Tag:   g.Tag,
+		}
+		if f.Type.Kind() == reflect.Slice {
+			f.Repeated = true
+			f.Type = f.Type.Elem()
+		}
+		for f.Type.Kind() == reflect.Ptr {
+			f.Type = f.Type.Elem()
+		}
+		if f.IsRecord() {
+			f.Fields = Fields(f.Type)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
@@ -0,0 +1,163 @@
+package scrape
+
+import (
+	"math"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// invisible are elements whose text is never displayed.
+var invisible = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+}
+
+// VisibleText is like Text, but it omits the text of elements that
+// aren't displayed, like <script> and <style>, and collapses runs of
+// whitespace into a single space.
+func VisibleText(n *html.Node) string {
+	var words []string
+	var getTextHelp func(node *html.Node)
+	getTextHelp = func(node *html.Node) {
+		switch {
+		case node == nil:
+			// Do nothing.
+		case node.Type == html.TextNode:
+			words = append(words, strings.Fields(node.Data)...)
+		case node.Type == html.ElementNode && invisible[node.DataAtom]:
+			// Do nothing.
+		default:
+			for next := node.FirstChild; next != nil; next = next.NextSibling {
+				getTextHelp(next)
+			}
+		}
+	}
+	getTextHelp(n)
+	return strings.Join(words, " ")
+}
+
+// boilerplate are elements that hold content repeated across pages
+// rather than the content of a particular page.
+var boilerplate = map[atom.Atom]bool{
+	atom.Nav:    true,
+	atom.Header: true,
+	atom.Footer: true,
+	atom.Aside:  true,
+	atom.Form:   true,
+}
+
+// MainContent returns the element of the tree n that most likely
+// holds the main content of the page, in the manner of Readability.
+// If the page has a <main> element (or an element with role="main"),
+// that is used. Otherwise paragraphs are scored by length, and each
+// paragraph's score is credited to its parent and, by half, to its
+// grandparent. The element with the highest score, discounted by the
+// proportion of its text that is in links, is the main content.
+//
+// MainContent returns nil if it can't find any content.
+func MainContent(n *html.Node) *html.Node {
+	if main := Query("main", nil, n); main != nil {
+		return main
+	}
+	if main := firstWithAttribute("role", "main", n); main != nil {
+		return main
+	}
+
+	scores := make(map[*html.Node]float64)
+	for _, p := range NodesByTagNames([]string{"p", "pre", "td", "blockquote"}, n) {
+		if inBoilerplate(p) {
+			continue
+		}
+		text := VisibleText(p)
+		if len(text) < 25 {
+			continue
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		if p.Parent != nil {
+			scores[p.Parent] += score
+			if p.Parent.Parent != nil {
+				scores[p.Parent.Parent] += score / 2
+			}
+		}
+	}
+
+	var best *html.Node
+	var bestScore float64
+	for node, score := range scores {
+		score *= 1 - linkDensity(node)
+		if score > bestScore || (score == bestScore && best != nil && precedes(node, best)) {
+			best, bestScore = node, score
+		}
+	}
+	return best
+}
+
+// firstWithAttribute returns the first element in n whose attribute
+// k has value v.
+func firstWithAttribute(k, v string, n *html.Node) *html.Node {
+	if matchAttribute(k, v, n) {
+		return n
+	}
+	for next := n.FirstChild; next != nil; next = next.NextSibling {
+		if el := firstWithAttribute(k, v, next); el != nil {
+			return el
+		}
+	}
+	return nil
+}
+
+// inBoilerplate reports whether n is within a boilerplate element.
+func inBoilerplate(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if boilerplate[p.DataAtom] {
+			return true
+		}
+	}
+	return false
+}
+
+// linkDensity is the proportion of the visible text of n that is the
+// text of links.
+func linkDensity(n *html.Node) float64 {
+	total := len(VisibleText(n))
+	if total == 0 {
+		return 0
+	}
+	var linked int
+	for _, a := range NodesByTagName("a", n) {
+		linked += len(VisibleText(a))
+	}
+	return float64(linked) / float64(total)
+}
+
+// precedes reports whether a starts before b in document order. It
+// is used to break ties between equally scored nodes, so that
+// MainContent is deterministic.
+func precedes(a, b *html.Node) bool {
+	for n := a; n != nil; n = next(n) {
+		if n == b {
+			return true
+		}
+	}
+	return false
+}
+
+// next returns the node after n in document order.
+func next(n *html.Node) *html.Node {
+	if n.FirstChild != nil {
+		return n.FirstChild
+	}
+	for ; n != nil; n = n.Parent {
+		if n.NextSibling != nil {
+			return n.NextSibling
+		}
+	}
+	return nil
+}
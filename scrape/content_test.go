@@ -0,0 +1,48 @@
+package scrape
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestMainContent(t *testing.T) {
+	f, err := os.Open("testdata/article.html")
+	if err != nil {
+		t.Fatalf("couldn't open test data")
+	}
+	defer f.Close()
+
+	doc, err := html.Parse(f)
+	if err != nil {
+		t.Fatalf("couldn't parse test data")
+	}
+
+	main := MainContent(doc)
+	if main == nil {
+		t.Fatalf("expected main content")
+	}
+	if c := Attribute("class", main); c != "story" {
+		t.Errorf("expected main content to be .story, got .%s", c)
+	}
+
+	text := VisibleText(main)
+	if strings.Contains(text, "tracking") {
+		t.Errorf("script text should not be visible: %q", text)
+	}
+	if !strings.HasPrefix(text, "The first paragraph") {
+		t.Errorf("unexpected main content text: %q", text)
+	}
+}
+
+func TestMainContentElement(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<body><div role="main">Short.</div></body>`))
+	if err != nil {
+		t.Fatalf("couldn't parse test data")
+	}
+	if main := MainContent(doc); VisibleText(main) != "Short." {
+		t.Errorf("expected role=main element to be main content")
+	}
+}
@@ -47,6 +47,26 @@ func NodesByTagName(tag string, node *html.Node) []*html.Node {
 	return find(node)
 }
 
+// NodesByTagNames is like NodesByTagName, but matches any of the tag
+// names in tags. Nodes are returned in document order.
+func NodesByTagNames(tags []string, node *html.Node) []*html.Node {
+	atoms := make(map[atom.Atom]bool)
+	for _, tag := range tags {
+		atoms[atom.Lookup([]byte(tag))] = true
+	}
+	var find func(*html.Node) []*html.Node
+	find = func(node *html.Node) (list []*html.Node) {
+		if node.Type == html.ElementNode && atoms[node.DataAtom] {
+			list = append(list, node)
+		}
+		for next := node.FirstChild; next != nil; next = next.NextSibling {
+			list = append(list, find(next)...)
+		}
+		return
+	}
+	return find(node)
+}
+
 func NodesByName(name string, node *html.Node) []*html.Node {
 	var list []*html.Node
 	if matchAttribute("name", name, node) {
@@ -93,6 +113,21 @@ func Attribute(key string, n *html.Node) string {
 	return ""
 }
 
+// LookupAttribute is like Attribute, but it also reports whether the
+// attribute is present, so that an empty attribute can be
+// distinguished from a missing one.
+func LookupAttribute(key string, n *html.Node) (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
 func Classes(node *html.Node) []string {
 	return strings.Fields(Attribute("class", node))
 }
@@ -26,3 +26,26 @@ func TestQuery(t *testing.T) {
 		t.Errorf(`expected string "Match this.", got %s`, txt)
 	}
 }
+
+func TestNodesByTagNames(t *testing.T) {
+	f, err := os.Open("testdata/simple.html")
+	if err != nil {
+		t.Errorf("couldn't open test data")
+	}
+
+	doc, err := html.Parse(f)
+	if err != nil {
+		t.Errorf("couldn't parse test data")
+	}
+
+	nodes := NodesByTagNames([]string{"h1", "p"}, doc)
+	want := []string{"h1", "p", "p", "h1"}
+	if len(nodes) != len(want) {
+		t.Fatalf("expected %d nodes, got %d", len(want), len(nodes))
+	}
+	for i, n := range nodes {
+		if n.Data != want[i] {
+			t.Errorf("expected node %d to be %s, got %s", i, want[i], n.Data)
+		}
+	}
+}
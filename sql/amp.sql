@@ -0,0 +1,22 @@
+-- Produces one row per AMP pairing seen in the crawl: a page that
+-- declares an AMP version with rel="amphtml". For each pair, show
+-- the status of the AMP page and whether its canonical points back
+-- to the page that declared it.
+WITH
+	q AS (SELECT * FROM crawl),
+
+	r AS (
+	SELECT
+		q.Address.Full AS FullAddress,
+		h.Address.Full AS AmpAddress
+	FROM q, UNNEST(HeadLinks) AS h
+	WHERE h.Rel = "amphtml" )
+
+SELECT
+	r.FullAddress,
+	r.AmpAddress,
+	amp.StatusCode AS AmpStatusCode,
+	amp.Canonical.Address.Full AS AmpCanonical,
+	COALESCE(amp.Canonical.Address.Full = r.FullAddress, false) AS Paired
+FROM r LEFT JOIN q AS amp ON r.AmpAddress = amp.Address.Full
+ORDER BY Paired, r.FullAddress
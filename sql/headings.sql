@@ -0,0 +1,35 @@
+-- Audit the heading structure of each page. Produces one row per
+-- page with its number of H1s, the first heading on the page, and the
+-- number of times the outline skips a level (e.g., an H2 followed
+-- directly by an H4).
+WITH
+	q AS (SELECT * FROM crawl),
+
+	-- r is one row per heading, along with the level of the
+	-- heading preceding it on the same page.
+	r AS (
+	SELECT
+		q.Address.Full AS FullAddress,
+		h.Level,
+		LAG(h.Level) OVER (
+			PARTITION BY q.Address.Full
+			ORDER BY h.Position) AS PrevLevel
+	FROM q, UNNEST(Headings) AS h ),
+
+	s AS (
+	SELECT
+		FullAddress,
+		COUNTIF(Level > PrevLevel + 1) AS SkippedLevels
+	FROM r
+	GROUP BY FullAddress )
+
+SELECT
+	q.Address.Full AS FullAddress,
+	COALESCE(q.HeadingCounts.H1, 0) AS H1Count,
+	(SELECT Text FROM UNNEST(q.Headings) WHERE Position = 0) AS FirstHeading,
+	COALESCE(s.SkippedLevels, 0) AS SkippedLevels
+FROM q LEFT JOIN s ON q.Address.Full = s.FullAddress
+WHERE q.StatusCode = 200
+ORDER BY
+	H1Count != 1 DESC,
+	SkippedLevels DESC
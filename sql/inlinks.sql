@@ -12,6 +12,7 @@ WITH
 		link.Address.Full AS FullAddress,
           	COUNT(q.Address) AS InLinks
 	FROM q, UNNEST(Links) AS link
+	WHERE link.Element IN ("a", "area")
 	GROUP BY link.Address.Full )
 
 -- Result is a table with information about addresses appearing as
@@ -0,0 +1,39 @@
+-- Compare the languages declared for each page with the language
+-- detected in its text. Produces one row per page where the <html
+-- lang> attribute, the Content-Language header, or the page's own
+-- hreflang annotation disagrees with the detected language. Only
+-- the primary language subtag (e.g., "de" in "de-AT") is compared.
+WITH
+	q AS (
+	SELECT
+		Address.Full AS FullAddress,
+		HTMLLang,
+		ContentLanguage,
+		(SELECT h.Hreflang FROM UNNEST(Hreflang) AS h
+		 WHERE h.Address.Full = Address.Full LIMIT 1) AS SelfHreflang,
+		DetectedLanguage,
+		DetectedLanguageConfidence
+	FROM crawl
+	WHERE StatusCode = 200 AND DetectedLanguage IS NOT NULL ),
+
+	r AS (
+	SELECT
+		*,
+		LOWER(SPLIT(HTMLLang, "-")[SAFE_OFFSET(0)]) AS HTMLLangPrimary,
+		LOWER(SPLIT(ContentLanguage, "-")[SAFE_OFFSET(0)]) AS ContentLanguagePrimary,
+		LOWER(SPLIT(SelfHreflang, "-")[SAFE_OFFSET(0)]) AS HreflangPrimary
+	FROM q )
+
+SELECT
+	FullAddress,
+	DetectedLanguage,
+	DetectedLanguageConfidence,
+	HTMLLang,
+	ContentLanguage,
+	SelfHreflang
+FROM r
+WHERE
+	HTMLLangPrimary != DetectedLanguage
+	OR ContentLanguagePrimary != DetectedLanguage
+	OR HreflangPrimary != DetectedLanguage
+ORDER BY DetectedLanguageConfidence DESC
@@ -0,0 +1,17 @@
+-- Produces one row for each pair of pages whose SimHashes differ in
+-- at most 3 bits, i.e., pages with nearly the same content. Unlike
+-- duplicate_body.sql, this finds pages that differ by a date, a
+-- related-items widget, and so on. Comparing every pair of pages is
+-- expensive for large crawls; `crawl dupes` is an alternative.
+WITH q AS (
+	SELECT Address.Full AS FullAddress, SimHash
+	FROM crawl
+	WHERE StatusCode = 200 AND SimHash IS NOT NULL AND SimHash != 0 )
+
+SELECT
+	a.FullAddress AS AddressA,
+	b.FullAddress AS AddressB,
+	BIT_COUNT(a.SimHash ^ b.SimHash) AS Distance
+FROM q AS a JOIN q AS b ON a.FullAddress < b.FullAddress
+WHERE BIT_COUNT(a.SimHash ^ b.SimHash) <= 3
+ORDER BY Distance, AddressA
@@ -1,4 +1,10 @@
--- List all addresses and whether they have a noindex tag
+-- List all addresses and whether they have a noindex directive that
+-- applies to all crawlers, in either a robots meta tag or an
+-- X-Robots-Tag header. Also show whether Googlebot specifically is
+-- told not to index the page.
 SELECT Address,
-       REGEXP_CONTAINS(Robots, r"\bnoindex\b") AS Noindex
+       EXISTS (SELECT 1 FROM UNNEST(RobotsDirectives)
+               WHERE Noindex AND UserAgent = "") AS Noindex,
+       EXISTS (SELECT 1 FROM UNNEST(RobotsDirectives)
+               WHERE Noindex AND UserAgent IN ("", "googlebot")) AS GooglebotNoindex
 FROM crawl
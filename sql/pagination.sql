@@ -0,0 +1,24 @@
+-- Produces one row per rel="next" or rel="prev" link seen in the
+-- crawl, showing the status of the target page and whether the
+-- target links back in the opposite direction.
+WITH
+	q AS (SELECT * FROM crawl),
+
+	r AS (
+	SELECT
+		q.Address.Full AS SourceAddress,
+		h.Address.Full AS TargetAddress,
+		h.Rel
+	FROM q, UNNEST(HeadLinks) AS h
+	WHERE h.Rel IN ("next", "prev") )
+
+SELECT
+	r.SourceAddress,
+	r.Rel,
+	r.TargetAddress,
+	target.StatusCode AS TargetStatusCode,
+	r.SourceAddress IN (
+		SELECT h.Address.Full FROM UNNEST(target.HeadLinks) AS h
+		WHERE h.Rel = IF(r.Rel = "next", "prev", "next")) AS Reciprocated
+FROM r LEFT JOIN q AS target ON r.TargetAddress = target.Address.Full
+ORDER BY r.SourceAddress
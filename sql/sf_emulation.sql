@@ -7,15 +7,18 @@ WITH
         SELECT
                 *,
                 COALESCE(Address.Full != Canonical.Address.Full, true) AS HasOtherCanonical,
-                REGEXP_CONTAINS(Robots, r"\bnoindex\b") AS Noindex,
-                REGEXP_CONTAINS(Robots, r"\bnofollow\b") AS Nofollow
+                EXISTS (SELECT 1 FROM UNNEST(RobotsDirectives)
+                        WHERE Noindex AND UserAgent = "") AS Noindex,
+                EXISTS (SELECT 1 FROM UNNEST(RobotsDirectives)
+                        WHERE Nofollow AND UserAgent = "") AS Nofollow
         FROM crawl ), -- your crawl here                                                                                                                                                       
 
         r AS ( -- count links to each page                                                                                                                                                    
         SELECT DISTINCT
                 target.Address.Full AS FullAddress,
                 COUNT(DISTINCT source.Address.Full) OVER (PARTITION BY target.Address.Full) AS InLinks
-        FROM q AS source, UNNEST(Links) AS target )
+        FROM q AS source, UNNEST(Links) AS target
+        WHERE target.Element IN ("a", "area") )
 
 SELECT DISTINCT
         Depth,
@@ -0,0 +1,230 @@
+// Package warc is an internal package of the tool Crawl, responsible
+// for archiving requests and responses in the WARC/1.1 format, so
+// that what a server returned can be retrieved after the crawl.
+//
+// Each record is compressed as a gzip member of its own, so a record
+// can be read by seeking to its offset in the file and decompressing
+// from there.
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Field is a named field of a WARC record header, or of a block of
+// type application/warc-fields.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// A Writer writes records to a WARC file. It is safe for concurrent
+// use.
+type Writer struct {
+	mu       sync.Mutex
+	f        *os.File
+	name     string
+	offset   int64
+	infoID   string
+	software string
+}
+
+// Create creates the WARC file named name, and writes a warcinfo
+// record describing it, naming software as the creator. If a file
+// already exists at name, it is an error, and the file is left as it
+// was.
+func Create(name, software string) (*Writer, error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if os.IsExist(err) {
+		return nil, fmt.Errorf("%s already exists, and won't be replaced by a new WARC file", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	w := &Writer{
+		f:        f,
+		name:     filepath.Base(name),
+		software: software,
+	}
+	w.infoID = newRecordID()
+	info := fieldsBlock([]Field{
+		{"software", software},
+		{"format", "WARC File Format 1.1"},
+	})
+	_, err = w.writeRecord([]Field{
+		{"WARC-Type", "warcinfo"},
+		{"WARC-Record-ID", w.infoID},
+		{"WARC-Date", warcDate(time.Now())},
+		{"WARC-Filename", w.name},
+		{"Content-Type", "application/warc-fields"},
+	}, info)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Name returns the base name of the WARC file.
+func (w *Writer) Name() string {
+	return w.name
+}
+
+// WriteExchange writes a request record, a response record, and, if
+// metadata is not empty, a metadata record for the response resp to
+// the request it carries, received at date. body is the body of the
+// response, which has already been read from resp.Body. If truncated
+// is true, body is only the beginning of the response body, and the
+// response record is marked with WARC-Truncated: length. It returns
+// the offset of the response record in the file.
+func (w *Writer) WriteExchange(resp *http.Response, body []byte, truncated bool, date time.Time, metadata []Field) (int64, error) {
+	// Requests are written as the client sends them, which is
+	// accurate as long as the client doesn't add headers of its
+	// own, like Accept-Encoding.
+	var req bytes.Buffer
+	if err := resp.Request.Write(&req); err != nil {
+		return 0, err
+	}
+	// Write the response as it was received, re-encoding the body
+	// with the transfer encoding, if any, that the client removed.
+	// A truncated body can't be re-encoded, so it follows the
+	// status line and headers as it is.
+	var res bytes.Buffer
+	if truncated {
+		fmt.Fprintf(&res, "HTTP/%d.%d %s\r\n", resp.ProtoMajor, resp.ProtoMinor, resp.Status)
+		resp.Header.Write(&res)
+		res.WriteString("\r\n")
+		res.Write(body)
+	} else {
+		dump := *resp
+		dump.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err := dump.Write(&res); err != nil {
+			return 0, err
+		}
+	}
+
+	uri := resp.Request.URL.String()
+	responseID := newRecordID()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err := w.writeRecord([]Field{
+		{"WARC-Type", "request"},
+		{"WARC-Record-ID", newRecordID()},
+		{"WARC-Date", warcDate(date)},
+		{"WARC-Target-URI", uri},
+		{"WARC-Concurrent-To", responseID},
+		{"WARC-Warcinfo-ID", w.infoID},
+		{"Content-Type", "application/http;msgtype=request"},
+	}, req.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	header := []Field{
+		{"WARC-Type", "response"},
+		{"WARC-Record-ID", responseID},
+		{"WARC-Date", warcDate(date)},
+		{"WARC-Target-URI", uri},
+		{"WARC-Warcinfo-ID", w.infoID},
+		{"WARC-Payload-Digest", digest(body)},
+		{"Content-Type", "application/http;msgtype=response"},
+	}
+	if truncated {
+		header = append(header, Field{"WARC-Truncated", "length"})
+	}
+	offset, err := w.writeRecord(header, res.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	if len(metadata) > 0 {
+		_, err = w.writeRecord([]Field{
+			{"WARC-Type", "metadata"},
+			{"WARC-Record-ID", newRecordID()},
+			{"WARC-Date", warcDate(date)},
+			{"WARC-Target-URI", uri},
+			{"WARC-Concurrent-To", responseID},
+			{"WARC-Warcinfo-ID", w.infoID},
+			{"Content-Type", "application/warc-fields"},
+		}, fieldsBlock(metadata))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return offset, nil
+}
+
+// writeRecord writes a record as a gzip member of its own, and
+// returns its offset. The block digest and length are added to
+// header. w.mu must be held, except while the file is created.
+func (w *Writer) writeRecord(header []Field, block []byte) (int64, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	fmt.Fprint(gz, "WARC/1.1\r\n")
+	header = append(header,
+		Field{"WARC-Block-Digest", digest(block)},
+		Field{"Content-Length", fmt.Sprint(len(block))})
+	for _, f := range header {
+		fmt.Fprintf(gz, "%s: %s\r\n", f.Name, f.Value)
+	}
+	fmt.Fprint(gz, "\r\n")
+	gz.Write(block)
+	fmt.Fprint(gz, "\r\n\r\n")
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+
+	offset := w.offset
+	n, err := w.f.Write(buf.Bytes())
+	w.offset += int64(n)
+	return offset, err
+}
+
+// Close closes the WARC file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f.Close()
+}
+
+// fieldsBlock formats fields as a block of type
+// application/warc-fields.
+func fieldsBlock(fields []Field) []byte {
+	var b strings.Builder
+	for _, f := range fields {
+		fmt.Fprintf(&b, "%s: %s\r\n", f.Name, f.Value)
+	}
+	return []byte(b.String())
+}
+
+func warcDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// digest returns the SHA-1 digest of b in the form conventional in
+// WARC files.
+func digest(b []byte) string {
+	sum := sha1.Sum(b)
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+// newRecordID returns a random (version 4) UUID as a WARC record ID.
+func newRecordID() string {
+	var u [16]byte
+	rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
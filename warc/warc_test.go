@@ -0,0 +1,193 @@
+package warc
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readRecord returns the header fields and block of the record at
+// offset in the file at path.
+func readRecord(t *testing.T, path string, offset int64) (map[string]string, string) {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, 0); err != nil {
+		t.Fatalf("%v", err)
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	gz.Multistream(false)
+	r := bufio.NewReader(gz)
+	if line, _ := r.ReadString('\n'); line != "WARC/1.1\r\n" {
+		t.Fatalf("record at %d starts with %q", offset, line)
+	}
+	header := make(map[string]string)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if line == "\r\n" {
+			break
+		}
+		i := strings.Index(line, ": ")
+		header[line[:i]] = strings.TrimSpace(line[i+2:])
+	}
+	rest, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var n int
+	fmt.Sscan(header["Content-Length"], &n)
+	if len(rest) != n+4 || !strings.HasSuffix(string(rest), "\r\n\r\n") {
+		t.Fatalf("record at %d has bad length: %q", offset, rest)
+	}
+	return header, string(rest[:n])
+}
+
+func TestWriteExchange(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<p>Hello</p>")
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "warc")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "crawl.warc.gz")
+
+	w, err := Create(path, "Crawl/test")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	resp, err := http.Get(ts.URL + "/page")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	offset, err := w.WriteExchange(resp, body, false, time.Now(), []Field{{"outlink", "http://example.com/"}})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	header, _ := readRecord(t, path, 0)
+	if header["WARC-Type"] != "warcinfo" || header["WARC-Filename"] != "crawl.warc.gz" {
+		t.Errorf("unexpected warcinfo record: %v", header)
+	}
+
+	header, block := readRecord(t, path, offset)
+	if header["WARC-Type"] != "response" {
+		t.Errorf("record at %d is a %s record", offset, header["WARC-Type"])
+	}
+	if header["WARC-Target-URI"] != ts.URL+"/page" {
+		t.Errorf("unexpected target %s", header["WARC-Target-URI"])
+	}
+	if header["WARC-Payload-Digest"] != digest(body) {
+		t.Errorf("unexpected payload digest %s", header["WARC-Payload-Digest"])
+	}
+	if !strings.HasPrefix(block, "HTTP/1.1 200 OK\r\n") || !strings.HasSuffix(block, "\r\n\r\n<p>Hello</p>") {
+		t.Errorf("unexpected response block %q", block)
+	}
+
+	// The whole file is a valid gzip stream holding all records.
+	f, _ := os.Open(path)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	all, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for _, typ := range []string{"warcinfo", "request", "response", "metadata"} {
+		if !strings.Contains(string(all), "WARC-Type: "+typ+"\r\n") {
+			t.Errorf("no %s record in file", typ)
+		}
+	}
+}
+
+func TestWriteExchangeTruncated(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "<p>Hello</p>")
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "warc")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "crawl.warc.gz")
+
+	w, err := Create(path, "Crawl/test")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	body := make([]byte, 3)
+	io.ReadFull(resp.Body, body)
+	resp.Body.Close()
+	offset, err := w.WriteExchange(resp, body, true, time.Now(), nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	header, block := readRecord(t, path, offset)
+	if header["WARC-Truncated"] != "length" {
+		t.Errorf("truncated record isn't marked: %v", header)
+	}
+	if !strings.Contains(block, "Content-Length: 12\r\n") || !strings.HasSuffix(block, "\r\n\r\n<p>") {
+		t.Errorf("unexpected response block %q", block)
+	}
+}
+
+func TestCreateExisting(t *testing.T) {
+	dir, err := ioutil.TempDir("", "warc")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "crawl.warc.gz")
+	if err := ioutil.WriteFile(path, []byte("existing"), 0666); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if _, err := Create(path, "Crawl/test"); err == nil {
+		t.Errorf("expected an error creating %s over an existing file", path)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if string(b) != "existing" {
+		t.Errorf("existing file was changed to %q", b)
+	}
+}